@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli"
 
 	"github.com/rliebz/tusk/runner"
+	"github.com/rliebz/tusk/ui"
 )
 
 // newBaseApp creates a basic cli.App with top-level flags.
@@ -40,6 +43,42 @@ func newBaseApp() *cli.App {
 			Name:  "v, verbose",
 			Usage: "Print verbose output",
 		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print what would be executed without running any commands",
+		},
+		cli.StringFlag{
+			Name:  "output-format",
+			Usage: "Set the `format` of output (one of: text, json)",
+		},
+		cli.StringFlag{
+			Name:  "output-mode",
+			Usage: "Set how concurrent output is shown (one of: prefix, group)",
+		},
+		cli.BoolFlag{
+			Name:  "timings",
+			Usage: "Print the time taken by each task and command",
+		},
+		cli.BoolFlag{
+			Name:  "explain-cache",
+			Usage: "Print why a task is or is not up to date instead of running it",
+		},
+		cli.BoolFlag{
+			Name:  "full-hash",
+			Usage: "Hash every source and target file, even if unchanged",
+		},
+		cli.StringFlag{
+			Name:  "artifact-cache",
+			Usage: "Store and restore task targets in a shared `location` (a directory or URL)",
+		},
+		cli.StringFlag{
+			Name:  "log-dir",
+			Usage: "Copy the output of each task to a file in a `directory`",
+		},
+		cli.BoolFlag{
+			Name:  "watch",
+			Usage: "Run the task again each time its watched files change",
+		},
 
 		// Commands
 		cli.BoolFlag{
@@ -58,6 +97,10 @@ func newBaseApp() *cli.App {
 			Name:  "uninstall-completion",
 			Usage: "Uninstall tab completion for a `shell` (one of: bash, fish, zsh)",
 		},
+		cli.BoolFlag{
+			Name:  "cache-stats",
+			Usage: "Print the size of the cache for each project and task",
+		},
 		cli.BoolFlag{
 			Name:  "clean-cache",
 			Usage: "Delete all cached files",
@@ -143,6 +186,8 @@ func NewApp(args []string, meta *Metadata) (*cli.App, error) {
 		Flags:       flagsPassed,
 		Interpreter: meta.Interpreter,
 		TaskName:    taskName,
+		DryRun:      meta.DryRun,
+		Prompter:    newPrompter(args, meta),
 	})
 	if err != nil {
 		return nil, err
@@ -174,6 +219,26 @@ func NewApp(args []string, meta *Metadata) (*cli.App, error) {
 	return app, nil
 }
 
+// newPrompter returns a prompter for missing values when a task is being run
+// from a terminal, or nil otherwise.
+func newPrompter(args []string, meta *Metadata) *ui.Prompter {
+	if IsCompleting(args) || meta.PrintHelp || meta.CleanTaskCache != "" {
+		return nil
+	}
+
+	// Prompts would be mixed into the events, which are meant to be read by
+	// other programs rather than a person.
+	if meta.Logger.Format() == ui.FormatJSON {
+		return nil
+	}
+
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+
+	return ui.NewPrompter(os.Stdin, meta.Logger.Stderr())
+}
+
 // getPassedValues returns the args and flags passed by command line.
 func getPassedValues(app *cli.App) (args []string, flags map[string]string, err error) {
 	argsPassed, ok := app.Metadata["argsPassed"].([]string)
@@ -13,17 +13,34 @@ type commandCreator func(app *cli.App, meta *Metadata, t *runner.Task) (*cli.Com
 
 func createExecuteCommand(_ *cli.App, meta *Metadata, t *runner.Task) (*cli.Command, error) {
 	return createCommand(t, func(c *cli.Context) error {
-		if len(t.Args) != len(c.Args()) {
+		// Missing args are prompted for or reported when the config is parsed.
+		if len(c.Args()) > len(t.Args) {
 			return fmt.Errorf(
 				"task %q requires exactly %d args, got %d",
 				t.Name, len(t.Args), len(c.Args()),
 			)
 		}
-		return t.Execute(runner.Context{
+
+		ctx := runner.Context{
 			CfgPath:     meta.CfgPath,
 			Logger:      meta.Logger,
 			Interpreter: meta.Interpreter,
-		})
+			DryRun:      meta.DryRun,
+			FullHash:    meta.FullHash,
+
+			ArtifactCache: meta.ArtifactCache,
+			LogDir:        meta.LogDir,
+		}.WithContext(meta.Context)
+
+		if meta.ExplainCache {
+			return t.ExplainCache(ctx, meta.Logger.Stdout())
+		}
+
+		if meta.Watch {
+			return t.ExecuteOnChange(ctx)
+		}
+
+		return t.Execute(ctx)
 	}), nil
 }
 
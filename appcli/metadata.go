@@ -1,6 +1,7 @@
 package appcli
 
 import (
+	gocontext "context"
 	"fmt"
 	"os"
 	"strings"
@@ -13,15 +14,26 @@ import (
 
 // Metadata contains global configuration settings.
 type Metadata struct {
+	// Context governs the cancellation of running tasks.
+	Context gocontext.Context
+
 	CfgPath     string
 	CfgText     []byte
 	Interpreter []string
 	Logger      *ui.Logger
+	DryRun      bool
+	FullHash    bool
+
+	ArtifactCache string
+	ExplainCache  bool
+	LogDir        string
+	Watch         bool
 
 	InstallCompletion   string
 	UninstallCompletion string
 	PrintHelp           bool
 	PrintVersion        bool
+	PrintCacheStats     bool
 	CleanCache          bool
 	CleanProjectCache   bool
 	CleanTaskCache      string
@@ -72,16 +84,38 @@ func (m *Metadata) set(o optGetter) error {
 		return err
 	}
 
+	format, err := ui.ParseFormat(o.String("output-format"))
+	if err != nil {
+		return err
+	}
+
+	mode, err := ui.ParseOutputMode(o.String("output-mode"))
+	if err != nil {
+		return err
+	}
+
 	m.CfgPath, m.CfgText = cfgPath, cfgText
 	m.Interpreter = interpreter
 	m.InstallCompletion = o.String("install-completion")
 	m.UninstallCompletion = o.String("uninstall-completion")
 	m.PrintHelp = o.Bool("help")
 	m.PrintVersion = o.Bool("version")
+	m.PrintCacheStats = o.Bool("cache-stats")
 	m.CleanCache = o.Bool("clean-cache")
 	m.CleanProjectCache = o.Bool("clean-project-cache")
 	m.CleanTaskCache = o.String("clean-task-cache")
+	m.DryRun = o.Bool("dry-run")
+	m.FullHash = o.Bool("full-hash")
+	m.ExplainCache = o.Bool("explain-cache")
+	m.ArtifactCache = o.String("artifact-cache")
+	m.LogDir = o.String("log-dir")
+	m.Watch = o.Bool("watch")
 	m.Logger.SetLevel(getLogLevel(o))
+	m.Logger.SetFormat(format)
+	m.Logger.SetOutputMode(mode)
+	if o.Bool("timings") {
+		m.Logger.RecordTimings()
+	}
 	return nil
 }
 
@@ -147,7 +181,7 @@ func getLogLevel(c optGetter) ui.Level {
 		return ui.LevelSilent
 	case c.Bool("quiet"):
 		return ui.LevelQuiet
-	case c.Bool("verbose"):
+	case c.Bool("verbose"), c.Bool("dry-run"):
 		return ui.LevelVerbose
 	default:
 		return ui.LevelNormal
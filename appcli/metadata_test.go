@@ -89,6 +89,16 @@ func TestNewMetadata_log_level(t *testing.T) {
 			[]string{"tusk", "--verbose"},
 			ui.LevelVerbose,
 		},
+		{
+			"dry run",
+			[]string{"tusk", "--dry-run"},
+			ui.LevelVerbose,
+		},
+		{
+			"quiet dry run",
+			[]string{"tusk", "--quiet", "--dry-run"},
+			ui.LevelQuiet,
+		},
 		{
 			"quiet verbose",
 			[]string{"tusk", "--quiet", "--verbose"},
@@ -158,6 +168,8 @@ func TestMetadata_Set(t *testing.T) {
 	silent := ui.New(ui.Config{Verbosity: ui.LevelSilent})
 	quiet := ui.New(ui.Config{Verbosity: ui.LevelQuiet})
 	verbose := ui.New(ui.Config{Verbosity: ui.LevelVerbose})
+	timed := ui.New(ui.Config{Verbosity: ui.LevelNormal})
+	timed.RecordTimings()
 
 	tests := []struct {
 		name    string
@@ -271,6 +283,75 @@ func TestMetadata_Set(t *testing.T) {
 				Logger: verbose,
 			},
 		},
+		{
+			name: "timings",
+			bools: map[string]bool{
+				"timings": true,
+			},
+			meta: Metadata{
+				Logger: timed,
+			},
+		},
+		{
+			name: "full-hash",
+			bools: map[string]bool{
+				"full-hash": true,
+			},
+			meta: Metadata{
+				FullHash: true,
+				Logger:   normal,
+			},
+		},
+		{
+			name: "explain-cache",
+			bools: map[string]bool{
+				"explain-cache": true,
+			},
+			meta: Metadata{
+				ExplainCache: true,
+				Logger:       normal,
+			},
+		},
+		{
+			name: "artifact-cache",
+			strings: map[string]string{
+				"artifact-cache": "/tmp/artifacts",
+			},
+			meta: Metadata{
+				ArtifactCache: "/tmp/artifacts",
+				Logger:        normal,
+			},
+		},
+		{
+			name: "log-dir",
+			strings: map[string]string{
+				"log-dir": "logs",
+			},
+			meta: Metadata{
+				LogDir: "logs",
+				Logger: normal,
+			},
+		},
+		{
+			name: "watch",
+			bools: map[string]bool{
+				"watch": true,
+			},
+			meta: Metadata{
+				Watch:  true,
+				Logger: normal,
+			},
+		},
+		{
+			name: "cache-stats",
+			bools: map[string]bool{
+				"cache-stats": true,
+			},
+			meta: Metadata{
+				PrintCacheStats: true,
+				Logger:          normal,
+			},
+		},
 		{
 			name: "verbosity-prefers-silence",
 			bools: map[string]bool{
@@ -368,3 +449,33 @@ func TestMetadata_Set_interpreter(t *testing.T) {
 		})
 	}
 }
+
+func TestMetadata_Set_output_format(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Chdir(fs.NewDir(t, "empty-dir").Path())
+
+	meta := Metadata{Logger: ui.New(ui.Config{})}
+	err := meta.set(mockOptGetter{strings: map[string]string{"output-format": "json"}})
+	g.NoError(err)
+	g.Should(be.Equal(meta.Logger.Format(), ui.FormatJSON))
+
+	meta = Metadata{Logger: ui.New(ui.Config{})}
+	err = meta.set(mockOptGetter{strings: map[string]string{"output-format": "xml"}})
+	g.Should(be.ErrorEqual(err, `invalid output format "xml" (one of: text, json)`))
+}
+
+func TestMetadata_Set_output_mode(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Chdir(fs.NewDir(t, "empty-dir").Path())
+
+	meta := Metadata{Logger: ui.New(ui.Config{})}
+	err := meta.set(mockOptGetter{strings: map[string]string{"output-mode": "group"}})
+	g.NoError(err)
+	g.Should(be.Equal(meta.Logger.OutputMode(), ui.OutputGroup))
+
+	meta = Metadata{Logger: ui.New(ui.Config{})}
+	err = meta.set(mockOptGetter{strings: map[string]string{"output-mode": "tee"}})
+	g.Should(be.ErrorEqual(err, `invalid output mode "tee" (one of: prefix, group)`))
+}
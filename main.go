@@ -2,6 +2,7 @@ package main
 
 import (
 	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -39,6 +40,7 @@ func run(cfg config) (status int) {
 		Stdout: cfg.stdout,
 		Stderr: cfg.stderr,
 	})
+	defer logger.Flush()
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -47,11 +49,15 @@ func run(cfg config) (status int) {
 		}
 	}()
 
+	ctx, stop := runner.NotifySignals(context.Background())
+	defer stop()
+
 	meta, err := appcli.NewMetadata(logger, cfg.args)
 	if err != nil && !appcli.IsCompleting(cfg.args) {
 		logError(logger, cfg.args, err)
 		return 1
 	}
+	meta.Context = ctx
 
 	status, err = runMeta(meta, cfg.args)
 	if err != nil && appcli.IsCompleting(cfg.args) && meta.CfgPath != "" {
@@ -77,6 +83,8 @@ func runMeta(meta *appcli.Metadata, args []string) (exitStatus int, err error) {
 		return 0, appcli.InstallCompletion(meta)
 	case meta.UninstallCompletion != "":
 		return 0, appcli.UninstallCompletion(meta)
+	case meta.PrintCacheStats:
+		return 0, runner.PrintCacheStats(meta.Logger.Stdout())
 	case meta.CleanCache:
 		return 0, runner.CleanCache()
 	case meta.CleanProjectCache:
@@ -113,12 +121,35 @@ func printVersion(meta *appcli.Metadata) {
 }
 
 func runApp(app *cli.App, meta *appcli.Metadata, args []string) (int, error) {
+	defer meta.Logger.PrintTimings()
+
 	if err := app.Run(args); err != nil {
+		// Interruptions are intentional, so there is no need to report them.
+		var sigErr *runner.SignalError
+		if errors.As(err, &sigErr) {
+			return sigErr.ExitCode(), nil
+		}
+
+		// Each failure has already been reported, so only a summary is needed.
+		var failuresErr *runner.FailuresError
+		if errors.As(err, &failuresErr) {
+			meta.Logger.PrintFailures(failuresErr.Failures)
+			return 1, nil
+		}
+
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
+			timedOut := runner.IsTimeout(err)
 			if meta.Logger.Level() < ui.LevelVerbose {
 				err = nil
 			}
+
+			// Commands that time out are stopped by tusk, so their exit status
+			// says nothing useful. Use the same status as timeout(1) instead.
+			if timedOut {
+				return 124, err
+			}
+
 			ws := exitErr.Sys().(syscall.WaitStatus)
 			return ws.ExitStatus(), err
 		}
@@ -52,17 +52,27 @@ Tasks:
    print-passed-values  Print values passed
 
 Global Options:
+       --artifact-cache <location>     Store and restore task targets in a shared location (a directory or URL)
+       --cache-stats                   Print the size of the cache for each project and task
        --clean-cache                   Delete all cached files
        --clean-project-cache           Delete cached files related to the current config file
        --clean-task-cache <value>      Delete cached files related to the given task
+       --dry-run                       Print what would be executed without running any commands
+       --explain-cache                 Print why a task is or is not up to date instead of running it
    -f, --file <file>                   Set file to use as the config file
+       --full-hash                     Hash every source and target file, even if unchanged
    -h, --help                          Show help and exit
        --install-completion <shell>    Install tab completion for a shell (one of: bash, fish, zsh)
+       --log-dir <directory>           Copy the output of each task to a file in a directory
+       --output-format <format>        Set the format of output (one of: text, json)
+       --output-mode <value>           Set how concurrent output is shown (one of: prefix, group)
    -q, --quiet                         Only print command output and application errors
    -s, --silent                        Print no output
+       --timings                       Print the time taken by each task and command
        --uninstall-completion <shell>  Uninstall tab completion for a shell (one of: bash, fish, zsh)
    -V, --version                       Print version and exit
    -v, --verbose                       Print verbose output
+       --watch                         Run the task again each time its watched files change
 `,
 		},
 		{
@@ -193,6 +203,27 @@ exit status 5
 	g.Should(be.Equal(status, 5))
 }
 
+func Test_run_exitCodeTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	g := ghost.New(t)
+
+	stderr := new(bytes.Buffer)
+
+	args := []string{"tusk", "-f", "./testdata/tusk.yml", "timeout"}
+	status := run(
+		config{
+			args:   args,
+			stderr: stderr,
+		},
+	)
+
+	g.Should(be.StringContaining(stderr.String(), `command "sleep 5" timed out after 50ms`))
+	g.Should(be.Equal(status, 124))
+}
+
 func Test_run_incorrect_usage(t *testing.T) {
 	g := ghost.New(t)
 
@@ -285,16 +316,26 @@ func Test_run_completion(t *testing.T) {
 		g.Should(be.Equal(status, 0))
 		// If we can't parse the config file, we can still show global flags
 		g.Should(be.Equal(stdout.String(), `normal
+--artifact-cache:Store and restore task targets in a shared location (a directory or URL)
+--cache-stats:Print the size of the cache for each project and task
 --clean-cache:Delete all cached files
 --clean-project-cache:Delete cached files related to the current config file
 --clean-task-cache:Delete cached files related to the given task
+--dry-run:Print what would be executed without running any commands
+--explain-cache:Print why a task is or is not up to date instead of running it
+--full-hash:Hash every source and target file, even if unchanged
 --help:Show help and exit
 --install-completion:Install tab completion for a shell (one of: bash, fish, zsh)
+--log-dir:Copy the output of each task to a file in a directory
+--output-format:Set the format of output (one of: text, json)
+--output-mode:Set how concurrent output is shown (one of: prefix, group)
 --quiet:Only print command output and application errors
 --silent:Print no output
+--timings:Print the time taken by each task and command
 --uninstall-completion:Uninstall tab completion for a shell (one of: bash, fish, zsh)
 --version:Print version and exit
 --verbose:Print verbose output
+--watch:Run the task again each time its watched files change
 `))
 		g.Should(be.Zero(stderr.String()))
 	})
@@ -320,16 +361,26 @@ func Test_run_completion(t *testing.T) {
 		g.Should(be.Equal(status, 0))
 		// If we can't parse the config file, we can still show global flags
 		g.Should(be.Equal(stdout.String(), `normal
+--artifact-cache:Store and restore task targets in a shared location (a directory or URL)
+--cache-stats:Print the size of the cache for each project and task
 --clean-cache:Delete all cached files
 --clean-project-cache:Delete cached files related to the current config file
 --clean-task-cache:Delete cached files related to the given task
+--dry-run:Print what would be executed without running any commands
+--explain-cache:Print why a task is or is not up to date instead of running it
+--full-hash:Hash every source and target file, even if unchanged
 --help:Show help and exit
 --install-completion:Install tab completion for a shell (one of: bash, fish, zsh)
+--log-dir:Copy the output of each task to a file in a directory
+--output-format:Set the format of output (one of: text, json)
+--output-mode:Set how concurrent output is shown (one of: prefix, group)
 --quiet:Only print command output and application errors
 --silent:Print no output
+--timings:Print the time taken by each task and command
 --uninstall-completion:Uninstall tab completion for a shell (one of: bash, fish, zsh)
 --version:Print version and exit
 --verbose:Print verbose output
+--watch:Run the task again each time its watched files change
 `))
 		g.Should(be.Zero(stderr.String()))
 	})
@@ -0,0 +1,380 @@
+package runner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// artifactStore stores archives of the targets of tasks, so that a task with
+// the same inputs can restore its targets rather than running again.
+type artifactStore interface {
+	// get returns a reader for the archive stored for a key, if there is one.
+	// The reader must be closed.
+	get(ctx context.Context, key string) (io.ReadCloser, bool, error)
+
+	// put stores the archive read from r for a key. Nothing is stored if r
+	// returns an error.
+	put(ctx context.Context, key string, r io.Reader) error
+}
+
+// newArtifactStore returns the store at a location, which is either an HTTP
+// URL or a directory. An empty location returns a nil store.
+func newArtifactStore(location string) (artifactStore, error) {
+	if location == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(location)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return &httpArtifactStore{url: u, client: http.DefaultClient}, nil
+	}
+
+	dir, err := filepath.Abs(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dirArtifactStore{dir: dir}, nil
+}
+
+// dirArtifactStore stores archives as files in a directory, which may be
+// shared by multiple checkouts or machines.
+type dirArtifactStore struct {
+	dir string
+}
+
+func (s *dirArtifactStore) get(_ context.Context, key string) (io.ReadCloser, bool, error) {
+	f, err := os.Open(filepath.Join(s.dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return f, true, nil
+}
+
+func (s *dirArtifactStore) put(_ context.Context, key string, r io.Reader) error {
+	// The directory is meant to be shared, so others may read from it.
+	if err := os.MkdirAll(s.dir, 0o755); err != nil { //nolint:gosec
+		return err
+	}
+
+	return copyFileAtomic(filepath.Join(s.dir, key), r, 0o644)
+}
+
+// httpArtifactStore stores archives on an HTTP server, reading them with GET
+// and writing them with PUT requests.
+type httpArtifactStore struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func (s *httpArtifactStore) get(ctx context.Context, key string) (io.ReadCloser, bool, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, s.url.JoinPath(key).String(), http.NoBody,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, true, nil
+	case http.StatusNotFound:
+		resp.Body.Close() //nolint:errcheck
+		return nil, false, nil
+	default:
+		resp.Body.Close() //nolint:errcheck
+		return nil, false, fmt.Errorf(
+			"GET %s: unexpected status %s", req.URL.Redacted(), resp.Status,
+		)
+	}
+}
+
+func (s *httpArtifactStore) put(ctx context.Context, key string, r io.Reader) error {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, s.url.JoinPath(key).String(), r,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("PUT %s: unexpected status %s", req.URL.Redacted(), resp.Status)
+	}
+
+	return nil
+}
+
+// artifactKey returns the key of the archive of a task's targets, which is
+// based on the same inputs as its cache path.
+func artifactKey(cachePath string) string {
+	return filepath.Base(cachePath) + ".tar.gz"
+}
+
+// restoreArtifact restores the targets of a task from the artifact store, and
+// reports whether it did. Failures are reported as warnings, since the task
+// can still be run instead.
+func (t *Task) restoreArtifact(
+	ctx Context,
+	store artifactStore,
+	cachePath string,
+	inputs taskInputs,
+	stats *statCache,
+) bool {
+	if store == nil || ctx.DryRun || !t.isCacheable() {
+		return false
+	}
+
+	archive, ok, err := store.get(ctx.Context(), artifactKey(cachePath))
+	if err != nil {
+		ctx.Logger.Warn("reading artifact cache:", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	defer archive.Close() //nolint:errcheck
+
+	if err := extractArchive(ctx.Dir(), archive); err != nil {
+		ctx.Logger.Warn("restoring targets:", err)
+		return false
+	}
+
+	if err := t.cache(ctx, cachePath, inputs, stats); err != nil {
+		ctx.Logger.Warn("restoring targets:", err)
+		return false
+	}
+
+	return true
+}
+
+// storeArtifact stores the targets of a task in the artifact store. Failures
+// are reported as warnings, since the task itself has succeeded.
+func (t *Task) storeArtifact(ctx Context, store artifactStore, cachePath string) {
+	if store == nil || !t.isCacheable() {
+		return
+	}
+
+	archive, err := archiveFiles(ctx.Context(), ctx.Dir(), t.Target)
+	if err != nil {
+		ctx.Logger.Warn("archiving targets:", err)
+		return
+	}
+	defer archive.Close() //nolint:errcheck
+
+	if err := store.put(ctx.Context(), artifactKey(cachePath), archive); err != nil {
+		ctx.Logger.Warn("writing artifact cache:", err)
+	}
+}
+
+// archiveFiles returns a reader for a gzipped tar archive of the files in a
+// directory that match the patterns. The archive is written as it is read, so
+// errors writing it are returned by the reader, which must be closed.
+func archiveFiles(ctx context.Context, root string, patterns []string) (io.ReadCloser, error) {
+	names, err := matchFiles(ctx, root, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, root, names)) //nolint:errcheck
+	}()
+
+	return pr, nil
+}
+
+// writeArchive writes a gzipped tar archive of the named files in a directory.
+func writeArchive(w io.Writer, root string, names []string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, name := range names {
+		if err := addToArchive(tw, root, name); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+// matchFiles returns the sorted paths of the files in a directory that match
+// the patterns.
+func matchFiles(ctx context.Context, root string, patterns []string) ([]string, error) {
+	g, ctx := errgroup.WithContext(ctx)
+
+	entries := make(chan entry)
+	g.Go(func() error {
+		defer close(entries)
+		return walkEntries(ctx, entries, "target", os.DirFS(root), patterns, nil)
+	})
+
+	var names []string
+	for entry := range entries {
+		names = append(names, entry.path)
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// addToArchive writes a single file to an archive.
+func addToArchive(tw *tar.Writer, root string, name string) error {
+	path := filepath.Join(root, filepath.FromSlash(name))
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		link, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	// Ownership is specific to the machine that created the archive.
+	header.Name = name
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractArchive writes the files in a gzipped tar archive to a directory. The
+// archive is read as it is extracted, so it is never held in memory in full.
+func extractArchive(root string, r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := extractFile(root, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractFile writes a single file from an archive to a directory. Files may
+// not be written outside of the directory, including through symlinks.
+func extractFile(root string, header *tar.Header, r io.Reader) error {
+	name := filepath.FromSlash(header.Name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid path in archive: %s", header.Name)
+	}
+
+	if err := checkNoSymlinkDirs(root, name); err != nil {
+		return err
+	}
+
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeReg:
+		return copyFileAtomic(path, r, header.FileInfo().Mode().Perm())
+	case tar.TypeSymlink:
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return os.Symlink(header.Linkname, path)
+	default:
+		return fmt.Errorf("unsupported file type in archive: %s", header.Name)
+	}
+}
+
+// checkNoSymlinkDirs checks that none of the existing directories between a
+// root and a relative path within it are symlinks.
+func checkNoSymlinkDirs(root string, name string) error {
+	dir := root
+	for part := range strings.SplitSeq(filepath.Dir(name), string(filepath.Separator)) {
+		if part == "." {
+			return nil
+		}
+
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("invalid path in archive: %s is within a symlink", name)
+		}
+	}
+
+	return nil
+}
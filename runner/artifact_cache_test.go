@@ -0,0 +1,284 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+const artifactCfgText = `
+tasks:
+  build:
+    source: input.txt
+    target: out/*
+    run:
+      - echo ran >> runs.txt
+      - mkdir -p out && cp input.txt out/app && chmod 755 out/app
+`
+
+// newCheckout returns a directory with the inputs of a task that uses the
+// artifact cache.
+func newCheckout(t *testing.T) string {
+	t.Helper()
+	g := ghost.New(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte("data"), 0o600)
+	g.NoError(err)
+
+	return dir
+}
+
+// executeInCheckout executes the task in a checkout with an artifact cache.
+func executeInCheckout(t *testing.T, dir string, artifactCache string) {
+	t.Helper()
+	g := ghost.New(t)
+
+	cfgPath := filepath.Join(dir, "tusk.yml")
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgPath:  cfgPath,
+		CfgText:  []byte(artifactCfgText),
+		TaskName: "build",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["build"].Execute(Context{
+		CfgPath:       cfgPath,
+		Logger:        ui.Noop(),
+		ArtifactCache: artifactCache,
+	})
+	g.NoError(err)
+}
+
+// newArtifactServer returns a server that stores whatever is put to it.
+func newArtifactServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	artifacts := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := artifacts[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data) //nolint:errcheck
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			artifacts[r.URL.Path] = data
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTask_Execute_artifact_cache(t *testing.T) {
+	tests := []struct {
+		name     string
+		location func(t *testing.T) string
+	}{
+		{
+			name:     "directory",
+			location: func(t *testing.T) string { return t.TempDir() },
+		},
+		{
+			name:     "http",
+			location: func(t *testing.T) string { return newArtifactServer(t).URL + "/cache" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			location := tt.location(t)
+
+			first := newCheckout(t)
+			executeInCheckout(t, first, location)
+
+			second := newCheckout(t)
+			executeInCheckout(t, second, location)
+
+			_, err := os.Stat(filepath.Join(second, "runs.txt"))
+			g.Should(be.ErrorIs(err, os.ErrNotExist))
+
+			got, err := os.ReadFile(filepath.Join(second, "out", "app"))
+			g.NoError(err)
+			g.Should(be.Equal(string(got), "data"))
+
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(filepath.Join(second, "out", "app"))
+				g.NoError(err)
+				g.Should(be.Equal(info.Mode().Perm(), 0o755))
+			}
+
+			// The restored targets are cached locally as well.
+			executeInCheckout(t, second, "")
+
+			_, err = os.Stat(filepath.Join(second, "runs.txt"))
+			g.Should(be.ErrorIs(err, os.ErrNotExist))
+		})
+	}
+}
+
+func TestTask_Execute_artifact_cache_unavailable(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := newCheckout(t)
+	executeInCheckout(t, dir, server.URL)
+
+	got, err := os.ReadFile(filepath.Join(dir, "runs.txt"))
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "ran\n"))
+}
+
+func TestTask_Execute_artifact_cache_truncated(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	location := t.TempDir()
+
+	executeInCheckout(t, newCheckout(t), location)
+
+	archives, err := filepath.Glob(filepath.Join(location, "*.tar.gz"))
+	g.NoError(err)
+	g.Must(be.SliceLen(archives, 1))
+
+	info, err := os.Stat(archives[0])
+	g.NoError(err)
+	err = os.Truncate(archives[0], info.Size()/2)
+	g.NoError(err)
+
+	// The archive is only found to be incomplete partway through extracting
+	// it, and the task is run instead.
+	dir := newCheckout(t)
+	executeInCheckout(t, dir, location)
+
+	got, err := os.ReadFile(filepath.Join(dir, "runs.txt"))
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "ran\n"))
+}
+
+func TestExtractArchive_invalid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires symlinks")
+	}
+
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+		wantErr string
+	}{
+		{
+			name: "parent directory",
+			headers: []*tar.Header{
+				{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+			wantErr: "invalid path in archive: ../evil.txt",
+		},
+		{
+			name: "absolute path",
+			headers: []*tar.Header{
+				{Name: "/evil.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+			wantErr: "invalid path in archive: /evil.txt",
+		},
+		{
+			name: "within symlink",
+			headers: []*tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0o777},
+				{Name: "link/evil.txt", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+			wantErr: "invalid path in archive: link/evil.txt is within a symlink",
+		},
+		{
+			name: "unsupported type",
+			headers: []*tar.Header{
+				{Name: "fifo", Typeflag: tar.TypeFifo, Mode: 0o644},
+			},
+			wantErr: "unsupported file type in archive: fifo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			for _, header := range tt.headers {
+				err := tw.WriteHeader(header)
+				g.NoError(err)
+			}
+			g.NoError(tw.Close())
+			g.NoError(gw.Close())
+
+			root := filepath.Join(t.TempDir(), "root")
+			err := os.Mkdir(root, 0o700)
+			g.NoError(err)
+
+			err = extractArchive(root, &buf)
+			g.Should(be.ErrorEqual(err, tt.wantErr))
+
+			_, err = os.Stat(filepath.Join(root, "..", "evil.txt"))
+			g.Should(be.ErrorIs(err, os.ErrNotExist))
+		})
+	}
+}
+
+func TestDirArtifactStore_put_error(t *testing.T) {
+	g := ghost.New(t)
+
+	dir := t.TempDir()
+	store := &dirArtifactStore{dir: dir}
+
+	r := io.MultiReader(
+		strings.NewReader("partial"),
+		iotest.ErrReader(errors.New("archive failed")),
+	)
+	err := store.put(t.Context(), "key", r)
+	g.Should(be.ErrorEqual(err, "archive failed"))
+
+	entries, err := os.ReadDir(dir)
+	g.NoError(err)
+	g.Should(be.SliceLen(entries, 0))
+}
@@ -0,0 +1,372 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// defaultReadyTimeout is how long to wait for a background command to become
+// ready, unless the probe sets its own timeout.
+const defaultReadyTimeout = 30 * time.Second
+
+// readyInterval is how often a ready probe is checked.
+var readyInterval = 100 * time.Millisecond
+
+// errBackgroundStopped is the cause of a background command being stopped
+// once the task that started it is done with it.
+var errBackgroundStopped = errors.New("background command stopped")
+
+// Ready is a probe that reports when a background command is ready, so that
+// the rest of the task can rely on it.
+type Ready struct {
+	// Command is a command that succeeds once the background command is ready.
+	Command string `yaml:"command,omitempty"`
+
+	// Port is a TCP port, or a host and port, that accepts connections once the
+	// background command is ready. The host defaults to localhost.
+	Port string `yaml:"port,omitempty"`
+
+	// Log is a regular expression that matches a line of output of the
+	// background command once it is ready.
+	Log string `yaml:"log,omitempty"`
+
+	// Timeout is how long to wait for the background command to become ready.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// pattern is the compiled form of Log.
+	pattern *regexp.Regexp
+}
+
+// UnmarshalYAML ensures that exactly one kind of probe is defined, and that a
+// log probe is a valid regular expression.
+func (r *Ready) UnmarshalYAML(unmarshal func(any) error) error {
+	type readyType Ready // Use new type to avoid recursion
+	var ready readyType
+	if err := unmarshal(&ready); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, probe := range []string{ready.Command, ready.Port, ready.Log} {
+		if probe != "" {
+			count++
+		}
+	}
+	if count != 1 {
+		return errors.New("ready must define exactly one of command, port, or log")
+	}
+
+	if ready.Log != "" {
+		pattern, err := regexp.Compile(ready.Log)
+		if err != nil {
+			return fmt.Errorf("invalid ready log pattern: %w", err)
+		}
+		ready.pattern = pattern
+	}
+
+	*r = Ready(ready)
+	return nil
+}
+
+// address returns the address to connect to for a port probe.
+func (r *Ready) address() string {
+	if strings.Contains(r.Port, ":") {
+		return r.Port
+	}
+
+	return net.JoinHostPort("localhost", r.Port)
+}
+
+// validateBackground checks that the settings of a background run item can be
+// used together.
+func (r *Run) validateBackground() error {
+	if r.Ready != nil && !r.Background {
+		return errors.New("ready can only be used with background")
+	}
+
+	if !r.Background {
+		return nil
+	}
+
+	switch {
+	case len(r.Command) == 0:
+		return errors.New("background can only be used with commands")
+	case r.Parallel != 0:
+		return errors.New("parallel cannot be used with background")
+	}
+
+	for _, command := range r.Command {
+		switch {
+		case command.Capture != "":
+			return errors.New("capture cannot be used with background")
+		case command.Retry != nil:
+			return errors.New("retry cannot be used with background")
+		}
+	}
+
+	return nil
+}
+
+// backgroundGroup is the background commands started by a single execution of
+// a task.
+type backgroundGroup struct {
+	mu    sync.Mutex
+	procs []*backgroundProcess
+}
+
+// backgroundProcess is a running background command.
+type backgroundProcess struct {
+	command *Command
+	cancel  context.CancelCauseFunc
+
+	// done is closed once the command has exited, after which err is set.
+	done chan struct{}
+	err  error
+}
+
+// stop stops every background command in the group, most recently started
+// first, and waits for them to exit.
+func (g *backgroundGroup) stop() {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	procs := g.procs
+	g.procs = nil
+	g.mu.Unlock()
+
+	for i := len(procs) - 1; i >= 0; i-- {
+		procs[i].cancel(errBackgroundStopped)
+		<-procs[i].done
+	}
+}
+
+// runBackground starts each command without waiting for it to exit. If the run
+// item has a ready probe, each command must be ready before the next starts.
+func (t *Task) runBackground(ctx Context, r *Run, s executionState) error {
+	for _, command := range r.Command {
+		if ctx.DryRun || !shouldBeQuiet(command, ctx) {
+			parenthetical := "background"
+			if s == stateFinally {
+				parenthetical = "finally, background"
+			}
+			ctx.Logger.PrintCommandWithParenthetical(
+				command.Print, parenthetical, ctx.TaskNames()...,
+			)
+		}
+
+		if ctx.DryRun {
+			continue
+		}
+
+		if err := startBackground(ctx, command, r.Ready); err != nil {
+			ctx.Logger.PrintCommandError(err)
+			return &commandError{command: command.Print, tasks: ctx.TaskNames(), err: err}
+		}
+	}
+
+	return nil
+}
+
+// startBackground starts a command in the background, then waits for it to be
+// ready. The command is stopped when the task's background group is.
+func startBackground(ctx Context, command *Command, ready *Ready) error {
+	cctx, cancel := context.WithCancelCause(ctx.Context())
+	ctx = ctx.WithContext(cctx)
+	ctx, cancelTimeout := withTimeout(ctx, command.Timeout, "command", command.Print)
+
+	// Output is prefixed, since it is interleaved with the rest of the task.
+	out := ctx
+	out.Logger = ctx.Logger.WithPrefix(commandLabel(command))
+	cmd := command.newCmd(out)
+
+	// Background commands do not read from the terminal, so they can always be
+	// stopped along with every process they start.
+	setOwnProcessGroup(cmd)
+
+	var logs *logMatcher
+	if ready != nil && ready.pattern != nil {
+		logs = newLogMatcher(ready.pattern)
+		cmd.Stdout = teeWriter(cmd.Stdout, logs)
+		cmd.Stderr = teeWriter(cmd.Stderr, logs)
+	}
+
+	proc := &backgroundProcess{command: command, cancel: cancel, done: make(chan struct{})}
+	start := timeNow()
+	if err := cmd.Start(); err != nil {
+		cancelTimeout()
+		cancel(err)
+		return err
+	}
+
+	go func() {
+		defer cancelTimeout()
+		proc.wait(ctx, cmd, out.Logger, start)
+	}()
+
+	ctx.background.mu.Lock()
+	ctx.background.procs = append(ctx.background.procs, proc)
+	ctx.background.mu.Unlock()
+
+	if ready == nil {
+		return nil
+	}
+
+	return proc.waitUntilReady(ctx, ready, logs)
+}
+
+// wait waits for the command to exit. A command that exits before it is
+// stopped is reported, since the rest of the task may depend on it.
+func (p *backgroundProcess) wait(ctx Context, cmd *exec.Cmd, out *ui.Logger, start time.Time) {
+	err := cmd.Wait()
+	out.Flush()
+	stopped := errors.Is(context.Cause(ctx.Context()), errBackgroundStopped)
+	if !stopped {
+		err = withCancelCause(ctx, err)
+	}
+
+	p.err = err
+	close(p.done)
+
+	ctx.Logger.PrintCommandCompleted(
+		p.command.Print, exitCode(err), timeNow().Sub(start), ctx.TaskNames()...,
+	)
+
+	if !stopped && ctx.Context().Err() == nil {
+		message := fmt.Sprintf("background command %q exited", p.command.Print)
+		if err != nil {
+			message += ": " + err.Error()
+		}
+		ctx.Logger.Warn(message)
+	}
+}
+
+// waitUntilReady waits until the probe succeeds, the command exits, or the
+// probe times out.
+func (p *backgroundProcess) waitUntilReady(ctx Context, ready *Ready, logs *logMatcher) error {
+	timeout := ready.Timeout
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+
+	parent := ctx.Context()
+	pctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	ctx = ctx.WithContext(pctx)
+
+	var matched <-chan struct{}
+	if logs != nil {
+		matched = logs.matched
+	}
+
+	for {
+		if ok, err := p.probe(ctx, ready, logs); ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-pctx.Done():
+			return notReady(parent, timeout)
+		case <-p.done:
+			return p.exitedBeforeReady()
+		case <-matched:
+		case <-time.After(readyInterval):
+		}
+	}
+}
+
+// notReady returns the error for a probe that did not succeed in time, or the
+// cause of the command being cancelled while it was checked.
+func notReady(parent context.Context, timeout time.Duration) error {
+	if parent.Err() != nil {
+		return context.Cause(parent)
+	}
+	return fmt.Errorf("background command was not ready after %s", timeout)
+}
+
+// exitedBeforeReady returns the error for a command that exited before its
+// probe succeeded.
+func (p *backgroundProcess) exitedBeforeReady() error {
+	if p.err != nil {
+		return fmt.Errorf("background command exited before it was ready: %w", p.err)
+	}
+	return errors.New("background command exited before it was ready")
+}
+
+// probe checks whether the background command is ready.
+func (p *backgroundProcess) probe(ctx Context, ready *Ready, logs *logMatcher) (bool, error) {
+	switch {
+	case logs != nil:
+		select {
+		case <-logs.matched:
+			return true, nil
+		default:
+			return false, nil
+		}
+	case ready.Port != "":
+		conn, err := net.DialTimeout("tcp", ready.address(), readyInterval)
+		if err != nil {
+			return false, nil //nolint:nilerr // The port is not accepting connections yet.
+		}
+		return true, conn.Close()
+	default:
+		cmd := newCmd(ctx, ready.Command)
+		return cmd.Run() == nil, nil
+	}
+}
+
+// teeWriter returns a writer that writes to both writers, skipping w if it is
+// nil.
+func teeWriter(w io.Writer, logs *logMatcher) io.Writer {
+	if w == nil {
+		return logs
+	}
+	return io.MultiWriter(w, logs)
+}
+
+// logMatcher is a writer that reports when a line written to it matches a
+// pattern.
+type logMatcher struct {
+	pattern *regexp.Regexp
+	matched chan struct{}
+
+	mu   sync.Mutex
+	line []byte
+	once sync.Once
+}
+
+// newLogMatcher returns a logMatcher for a pattern.
+func newLogMatcher(pattern *regexp.Regexp) *logMatcher {
+	return &logMatcher{pattern: pattern, matched: make(chan struct{})}
+}
+
+// Write checks each complete line for a match.
+func (m *logMatcher) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.line = append(m.line, p...)
+	for {
+		i := bytes.IndexByte(m.line, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if m.pattern.Match(bytes.TrimSuffix(m.line[:i], []byte("\r"))) {
+			m.once.Do(func() { close(m.matched) })
+		}
+		m.line = m.line[i+1:]
+	}
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+)
+
+func TestTask_Execute_background_terminal(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	tty := openTerminal(t)
+
+	// The task is run by a separate process, so that the terminal can be its
+	// controlling terminal.
+	cmd := exec.Command(os.Args[0], "-test.run=TestTask_Execute_background_terminal_helper")
+	cmd.Env = append(os.Environ(), "TUSK_TEST_BACKGROUND_HELPER=1")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	err := cmd.Run()
+	g.NoError(err)
+
+	// Every process started by the background command is stopped along with
+	// it, before the finally clause runs.
+	status, err := os.ReadFile("status.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(status), "stopped\n"))
+}
+
+// TestTask_Execute_background_terminal_helper runs a task with a background
+// command from a terminal. It only runs when TUSK_TEST_BACKGROUND_HELPER is set
+// to "1".
+func TestTask_Execute_background_terminal_helper(t *testing.T) {
+	if os.Getenv("TUSK_TEST_BACKGROUND_HELPER") != "1" {
+		return
+	}
+
+	err := executeTask(t, `
+tasks:
+  main:
+    run:
+      - background: true
+        command: sleep 30 & echo $! > sleep.pid.tmp && mv sleep.pid.tmp sleep.pid; wait
+        ready:
+          command: test -f sleep.pid
+      - echo done
+    finally: >-
+      pid=$(cat sleep.pid);
+      for i in $(seq 50); do
+        state=$(awk '/^State/ {print $2}' /proc/$pid/status 2>/dev/null);
+        if [ -z "$state" ] || [ "$state" = Z ]; then echo stopped > status.txt; exit; fi;
+        sleep 0.1;
+      done;
+      kill -9 $pid; echo running > status.txt
+`, "main")
+	if err != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// openTerminal returns the terminal end of a new pseudo-terminal. Anything
+// written to it is discarded.
+func openTerminal(t *testing.T) *os.File {
+	t.Helper()
+	g := ghost.New(t)
+
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	g.NoError(err)
+	t.Cleanup(func() { ptmx.Close() }) //nolint:errcheck
+
+	var unlock int32
+	g.NoError(ioctl(ptmx, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)))
+
+	var n uint32
+	g.NoError(ioctl(ptmx, syscall.TIOCGPTN, unsafe.Pointer(&n)))
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	g.NoError(err)
+	t.Cleanup(func() { tty.Close() }) //nolint:errcheck
+
+	go io.Copy(io.Discard, ptmx) //nolint:errcheck
+
+	return tty
+}
+
+func ioctl(f *os.File, req uint, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(req), uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+)
+
+func TestRun_UnmarshalYAML_background_invalid(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{
+			input:   `{command: serve, ready: {port: 8080}}`,
+			wantErr: "ready can only be used with background",
+		},
+		{
+			input:   `{task: serve, background: true}`,
+			wantErr: "background can only be used with commands",
+		},
+		{
+			input:   `{command: [one, two], background: true, parallel: true}`,
+			wantErr: "parallel cannot be used with background",
+		},
+		{
+			input:   `{command: {exec: serve, capture: out}, background: true}`,
+			wantErr: "capture cannot be used with background",
+		},
+		{
+			input:   `{command: {exec: serve, retry: 3}, background: true}`,
+			wantErr: "retry cannot be used with background",
+		},
+		{
+			input:   `{command: serve, background: true, ready: {port: 8080, log: ready}}`,
+			wantErr: "ready must define exactly one of command, port, or log",
+		},
+		{
+			input:   `{command: serve, background: true, ready: {timeout: 1s}}`,
+			wantErr: "ready must define exactly one of command, port, or log",
+		},
+		{
+			input:   `{command: serve, background: true, ready: {log: "listening ("}}`,
+			wantErr: "invalid ready log pattern: error parsing regexp: missing closing ): `listening (`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var r Run
+			err := yaml.UnmarshalStrict([]byte(tt.input), &r)
+			g.Should(be.ErrorEqual(err, tt.wantErr))
+		})
+	}
+}
+
+func TestTask_Execute_background(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	tests := []struct {
+		name  string
+		ready string
+	}{
+		{
+			name:  "log",
+			ready: `{log: "^listening on \\d+$"}`,
+		},
+		{
+			name:  "command",
+			ready: `{command: test -f started.txt}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			xtesting.UseTempDir(t)
+
+			start := time.Now()
+			err := executeTask(t, `
+tasks:
+  dev:
+    grace-period: 100ms
+    run:
+      - background: true
+        ready: `+tt.ready+`
+        command: |
+          sleep 0.1
+          touch started.txt
+          echo "listening on 8080"
+          sleep 10
+          touch finished.txt
+      - test -f started.txt && touch checked.txt
+`, "dev")
+			g.NoError(err)
+			g.Should(be.True(time.Since(start) < 5*time.Second))
+
+			_, err = os.Stat("checked.txt")
+			g.NoError(err)
+
+			_, err = os.Stat("finished.txt")
+			g.Should(be.ErrorIs(err, os.ErrNotExist))
+		})
+	}
+}
+
+func TestTask_Execute_background_port(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	g.NoError(err)
+	t.Cleanup(func() { listener.Close() }) //nolint:errcheck
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	err = executeTask(t, `
+tasks:
+  dev:
+    grace-period: 100ms
+    run:
+      - background: true
+        ready: {port: `+strconv.Itoa(port)+`}
+        command: sleep 10
+      - touch checked.txt
+`, "dev")
+	g.NoError(err)
+
+	_, err = os.Stat("checked.txt")
+	g.NoError(err)
+}
+
+func TestTask_Execute_background_stopped_before_finally(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  dev:
+    run:
+      - background: true
+        ready: {command: test -f trapped.txt}
+        command: |
+          trap 'touch stopped.txt; exit 0' TERM
+          touch trapped.txt
+          while true; do sleep 0.05; done
+    finally: test -f stopped.txt && touch finally.txt
+`, "dev")
+	g.NoError(err)
+
+	_, err = os.Stat("finally.txt")
+	g.NoError(err)
+}
+
+func TestTask_Execute_background_not_ready(t *testing.T) {
+	tests := []struct {
+		name    string
+		ready   string
+		command string
+		wantErr string
+	}{
+		{
+			name:    "exited",
+			ready:   `{log: never}`,
+			command: "exit 3",
+			wantErr: "background command exited before it was ready: exit status 3",
+		},
+		{
+			name:    "timeout",
+			ready:   `{command: "false", timeout: 200ms}`,
+			command: "sleep 10",
+			wantErr: "background command was not ready after 200ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			xtesting.UseTempDir(t)
+
+			err := executeTask(t, `
+tasks:
+  dev:
+    grace-period: 100ms
+    run:
+      - background: true
+        ready: `+tt.ready+`
+        command: `+tt.command+`
+      - touch checked.txt
+`, "dev")
+			g.Should(be.ErrorContaining(err, tt.wantErr))
+
+			_, err = os.Stat("checked.txt")
+			g.Should(be.ErrorIs(err, os.ErrNotExist))
+		})
+	}
+}
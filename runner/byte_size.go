@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ByteSize is a number of bytes. In YAML, it may be written as a plain number
+// of bytes, or with a decimal (KB, MB, GB, TB) or binary (KiB, MiB, GiB, TiB)
+// unit, such as 500MB or 1GiB.
+type ByteSize int64
+
+var byteSizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMGT]i?B|B)?$`)
+
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// UnmarshalYAML parses a size with an optional unit.
+func (b *ByteSize) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	size, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+
+	*b = size
+	return nil
+}
+
+// parseByteSize parses a size with an optional unit.
+func parseByteSize(s string) (ByteSize, error) {
+	matches := byteSizePattern.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid size %q, expected a number with a unit such as 500MB", s)
+	}
+
+	n, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	return ByteSize(n * byteSizeUnits[matches[2]]), nil
+}
+
+// String returns the size with the largest binary unit that keeps it at least
+// one, such as 1.5 KiB.
+func (b ByteSize) String() string {
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+
+	if b < 1<<10 {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	size := float64(b) / (1 << 10)
+	unit := units[0]
+	for _, u := range units[1:] {
+		if size < 1<<10 {
+			break
+		}
+		size /= 1 << 10
+		unit = u
+	}
+
+	return fmt.Sprintf("%.1f %s", size, unit)
+}
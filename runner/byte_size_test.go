@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestByteSize_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		wantErr string
+	}{
+		{input: "512", want: 512},
+		{input: "512B", want: 512},
+		{input: "1.5KB", want: 1500},
+		{input: "500 MB", want: 500_000_000},
+		{input: "2GB", want: 2_000_000_000},
+		{input: "1TB", want: 1_000_000_000_000},
+		{input: "1KiB", want: 1 << 10},
+		{input: "1.5MiB", want: 3 << 19},
+		{input: "1GiB", want: 1 << 30},
+		{input: "1TiB", want: 1 << 40},
+		{input: "-1MB", wantErr: `invalid size "-1MB", expected a number with a unit such as 500MB`},
+		{input: "1mb", wantErr: `invalid size "1mb", expected a number with a unit such as 500MB`},
+		{input: "MB", wantErr: `invalid size "MB", expected a number with a unit such as 500MB`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var got ByteSize
+			err := yaml.UnmarshalStrict([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				g.Should(be.ErrorEqual(err, tt.wantErr))
+				return
+			}
+
+			g.NoError(err)
+			g.Should(be.Equal(got, tt.want))
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := []struct {
+		size ByteSize
+		want string
+	}{
+		{size: 0, want: "0 B"},
+		{size: 1023, want: "1023 B"},
+		{size: 1 << 10, want: "1.0 KiB"},
+		{size: 1536, want: "1.5 KiB"},
+		{size: 5 << 20, want: "5.0 MiB"},
+		{size: 3 << 30, want: "3.0 GiB"},
+		{size: 2 << 40, want: "2.0 TiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			g := ghost.New(t)
+			g.Should(be.Equal(tt.size.String(), tt.want))
+		})
+	}
+}
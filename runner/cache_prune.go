@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// defaultCacheMaxAge is how long a task cache entry is kept after it was last
+// used, unless configured otherwise.
+const defaultCacheMaxAge = 30 * 24 * time.Hour
+
+// CacheConfig configures when the task cache entries of a project are pruned.
+type CacheConfig struct {
+	// MaxAge is how long an entry is kept after it was last used. Defaults to
+	// 30 days.
+	MaxAge time.Duration `yaml:"max-age,omitempty"`
+
+	// MaxSize is the total size of entries to keep, after which the least
+	// recently used entries are deleted. The size is unlimited if unset.
+	MaxSize ByteSize `yaml:"max-size,omitempty"`
+}
+
+// isValid checks whether a cache configuration is valid.
+func (c CacheConfig) isValid() error {
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cache max-age must not be negative, got %s", c.MaxAge)
+	}
+
+	return nil
+}
+
+// pruneCache prunes the task cache of the project once the task being run has
+// finished. Failures are reported as warnings, since the task itself is done.
+func (t *Task) pruneCache(ctx Context) {
+	if t.cacheConfig == nil || ctx.DryRun {
+		return
+	}
+
+	if err := pruneProjectCache(ctx.CfgPath, *t.cacheConfig); err != nil {
+		ctx.Logger.Warn("pruning cache:", err)
+	}
+}
+
+// pruneProjectCache deletes the task cache entries of a project that have not
+// been used within the maximum age, then deletes the least recently used
+// entries until the rest fit within the maximum size.
+func pruneProjectCache(cfgPath string, cfg CacheConfig) error {
+	projectDir, err := projectCacheDir(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	files, err := projectCacheEntryFiles(projectDir)
+	if err != nil {
+		return err
+	}
+
+	maxAge := cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultCacheMaxAge
+	}
+	cutoff := timeNow().Add(-maxAge)
+
+	var size ByteSize
+	for _, f := range files {
+		size += ByteSize(f.info.Size())
+
+		expired := f.info.ModTime().Before(cutoff)
+		oversize := cfg.MaxSize > 0 && size > cfg.MaxSize
+		if !expired && !oversize {
+			continue
+		}
+
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// projectCacheEntryFiles returns the entries in every task cache directory of a
+// project, most recently used first.
+func projectCacheEntryFiles(projectDir string) ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(projectDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []cacheFile
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+
+		taskFiles, err := cacheEntryFiles(filepath.Join(projectDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, taskFiles...)
+	}
+
+	slices.SortFunc(files, func(a, b cacheFile) int {
+		return b.info.ModTime().Compare(a.info.ModTime())
+	})
+
+	return files, nil
+}
+
+// touchCacheEntry marks a cache entry as used, so that it is pruned last.
+func touchCacheEntry(cachePath string) error {
+	now := timeNow()
+	return os.Chtimes(cachePath, now, now)
+}
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+// writeCacheFile writes a file of the given size and age to the task cache
+// directory of a project.
+func writeCacheFile(t *testing.T, projectDir, name string, size int, age time.Duration) {
+	t.Helper()
+	g := ghost.New(t)
+
+	path := filepath.Join(projectDir, name)
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	g.NoError(err)
+
+	err = os.WriteFile(path, make([]byte, size), 0o600)
+	g.NoError(err)
+
+	modTime := time.Now().Add(-age)
+	err = os.Chtimes(path, modTime, modTime)
+	g.NoError(err)
+}
+
+func TestPruneProjectCache(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CacheConfig
+		want []string
+	}{
+		{
+			name: "default max age",
+			want: []string{"build/new", "build/old", "test/older"},
+		},
+		{
+			name: "max age",
+			cfg:  CacheConfig{MaxAge: 90 * time.Minute},
+			want: []string{"build/new", "build/old"},
+		},
+		{
+			name: "max size",
+			cfg:  CacheConfig{MaxSize: 250},
+			want: []string{"build/new", "build/old"},
+		},
+		{
+			name: "max size keeps most recently used",
+			cfg:  CacheConfig{MaxSize: 150},
+			want: []string{"build/new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			projectDir, err := projectCacheDir("tusk.yml")
+			g.NoError(err)
+
+			writeCacheFile(t, projectDir, "build/new", 100, 0)
+			writeCacheFile(t, projectDir, "build/old", 100, time.Hour)
+			writeCacheFile(t, projectDir, "build/"+statCacheFile, 1000, 0)
+			writeCacheFile(t, projectDir, "test/older", 100, 2*time.Hour)
+			writeCacheFile(t, projectDir, "test/expired", 100, 31*24*time.Hour)
+
+			err = pruneProjectCache("tusk.yml", tt.cfg)
+			g.NoError(err)
+
+			files, err := projectCacheEntryFiles(projectDir)
+			g.NoError(err)
+
+			got := make([]string, 0, len(files))
+			for _, f := range files {
+				rel, err := filepath.Rel(projectDir, f.path)
+				g.NoError(err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			g.Should(be.DeepEqual(got, tt.want))
+
+			_, err = os.Stat(filepath.Join(projectDir, "build", statCacheFile))
+			g.NoError(err)
+		})
+	}
+}
+
+func TestTask_Execute_prunes_cache(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cfgText := []byte(`
+cache:
+  max-age: 1h
+tasks:
+  build:
+    source: input.txt
+    target: build.txt
+    run: cp input.txt build.txt
+  test:
+    source: input.txt
+    target: test.txt
+    run: cp input.txt test.txt
+`)
+
+	execute := func(taskName string) {
+		t.Helper()
+
+		cfg, err := ParseComplete(&ParseConfig{
+			CfgPath:  "tusk.yml",
+			CfgText:  cfgText,
+			TaskName: taskName,
+		})
+		g.NoError(err)
+
+		err = cfg.Tasks[taskName].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+		g.NoError(err)
+	}
+
+	// backdate marks every cached run of a task as last used two hours ago.
+	backdate := func(taskName string) {
+		t.Helper()
+
+		dir, err := taskCacheDir("tusk.yml", taskName)
+		g.NoError(err)
+
+		files, err := cacheEntryFiles(dir)
+		g.NoError(err)
+		g.Must(be.SliceLen(files, 1))
+
+		modTime := time.Now().Add(-2 * time.Hour)
+		err = os.Chtimes(files[0].path, modTime, modTime)
+		g.NoError(err)
+	}
+
+	// cached returns the number of cached runs of a task.
+	cached := func(taskName string) int {
+		t.Helper()
+
+		dir, err := taskCacheDir("tusk.yml", taskName)
+		g.NoError(err)
+
+		files, err := cacheEntryFiles(dir)
+		g.NoError(err)
+
+		return len(files)
+	}
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	execute("build")
+	execute("test")
+
+	// Using a cache entry keeps it from being pruned.
+	backdate("build")
+	execute("build")
+	g.Should(be.Equal(cached("build"), 1))
+
+	backdate("build")
+	execute("test")
+	g.Should(be.Equal(cached("build"), 0))
+	g.Should(be.Equal(cached("test"), 1))
+}
@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// cacheUsage is the disk usage of part of the cache.
+type cacheUsage struct {
+	name    string
+	size    ByteSize
+	entries int
+}
+
+// String returns the name, size, and number of entries.
+func (u cacheUsage) String() string {
+	noun := "entries"
+	if u.entries == 1 {
+		noun = "entry"
+	}
+
+	return fmt.Sprintf("%s: %s in %d %s", u.name, u.size, u.entries, noun)
+}
+
+// projectUsage is the disk usage of the task cache of a project.
+type projectUsage struct {
+	cacheUsage
+	tasks []cacheUsage
+}
+
+// PrintCacheStats prints the size of the cache of each project and each of its
+// tasks, along with the size of the cached option values.
+func PrintCacheStats(w io.Writer) error {
+	cacheDir, err := tuskCacheDir()
+	if err != nil {
+		return err
+	}
+
+	dirEntries, err := os.ReadDir(cacheDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var projects []projectUsage
+	values := cacheUsage{name: "Option values"}
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(cacheDir, d.Name())
+		if d.Name() == valuesCacheDir {
+			values.size, values.entries, err = dirUsage(dir)
+		} else {
+			var project projectUsage
+			project, err = projectCacheUsage(dir)
+			projects = append(projects, project)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	slices.SortFunc(projects, func(a, b projectUsage) int { return cmp.Compare(a.name, b.name) })
+
+	total := values.size
+	for _, project := range projects {
+		fmt.Fprintln(w, project)
+		for _, task := range project.tasks {
+			fmt.Fprintf(w, "  %s\n", task)
+		}
+		total += project.size
+	}
+
+	fmt.Fprintln(w, values)
+	fmt.Fprintf(w, "Total: %s\n", total)
+
+	return nil
+}
+
+// projectCacheUsage returns the disk usage of the task cache of a project.
+//
+// Projects and tasks are named after the config file and task recorded in
+// their cache entries, or their directory if no entry records them.
+func projectCacheUsage(projectDir string) (projectUsage, error) {
+	project := projectUsage{
+		cacheUsage: cacheUsage{name: fmt.Sprintf("Unknown project (%s)", filepath.Base(projectDir))},
+	}
+
+	dirEntries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return projectUsage{}, err
+	}
+
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+
+		task, cfgPath, err := taskCacheUsage(filepath.Join(projectDir, d.Name()))
+		if err != nil {
+			return projectUsage{}, err
+		}
+
+		if cfgPath != "" {
+			project.name = cfgPath
+		}
+		project.size += task.size
+		project.entries += task.entries
+		project.tasks = append(project.tasks, task)
+	}
+
+	slices.SortFunc(project.tasks, func(a, b cacheUsage) int { return cmp.Compare(a.name, b.name) })
+
+	return project, nil
+}
+
+// taskCacheUsage returns the disk usage of a task's cache directory, along with
+// the config file path recorded in its entries.
+func taskCacheUsage(taskDir string) (cacheUsage, string, error) {
+	task := cacheUsage{name: fmt.Sprintf("Unknown task (%s)", filepath.Base(taskDir))}
+
+	files, err := cacheEntryFiles(taskDir)
+	if err != nil {
+		return cacheUsage{}, "", err
+	}
+
+	var cfgPath string
+	for _, f := range files {
+		entry, err := readCacheEntry(f.path)
+		if err != nil {
+			return cacheUsage{}, "", err
+		}
+
+		if entry.Task != "" && cfgPath == "" {
+			task.name, cfgPath = entry.Task, entry.Config
+		}
+		task.size += ByteSize(f.info.Size())
+		task.entries++
+	}
+
+	// The stat cache is not an entry, but still takes up space.
+	if info, err := os.Stat(filepath.Join(taskDir, statCacheFile)); err == nil {
+		task.size += ByteSize(info.Size())
+	}
+
+	return task, cfgPath, nil
+}
+
+// dirUsage returns the total size and number of regular files in a directory.
+func dirUsage(dir string) (ByteSize, int, error) {
+	var size ByteSize
+	var files int
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += ByteSize(info.Size())
+		files++
+		return nil
+	})
+
+	return size, files, err
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestPrintCacheStats(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cfgText := []byte(`
+tasks:
+  build:
+    options:
+      release:
+        type: bool
+    source: input.txt
+    target: out.txt
+    run: cp input.txt out.txt && echo ${release}
+`)
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	for _, release := range []string{"false", "true"} {
+		cfg, err := ParseComplete(&ParseConfig{
+			CfgPath:  "tusk.yml",
+			CfgText:  cfgText,
+			Flags:    map[string]string{"release": release},
+			TaskName: "build",
+		})
+		g.NoError(err)
+
+		err = cfg.Tasks["build"].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+		g.NoError(err)
+	}
+
+	cfgPath, err := filepath.Abs("tusk.yml")
+	g.NoError(err)
+
+	var buf bytes.Buffer
+	err = PrintCacheStats(&buf)
+	g.NoError(err)
+
+	const size = `\d+(\.\d)? (B|KiB)`
+	got := buf.String()
+	for _, line := range []string{
+		regexp.QuoteMeta(cfgPath) + ": " + size + " in 2 entries",
+		"  build: " + size + " in 2 entries",
+		"Option values: 0 B in 0 entries",
+		"Total: " + size,
+	} {
+		g.Should(be.StringMatching(got, "(?m)^"+line+"$"))
+	}
+}
+
+func TestPrintCacheStats_empty(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	err := PrintCacheStats(&buf)
+	g.NoError(err)
+	g.Should(be.Equal(buf.String(), "Option values: 0 B in 0 entries\nTotal: 0 B\n"))
+}
@@ -1,9 +1,15 @@
 package runner
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/rliebz/tusk/marshal"
 	"github.com/rliebz/tusk/ui"
@@ -11,8 +17,11 @@ import (
 
 var defaultInterpreter = []string{"sh", "-c"}
 
+// captureNamePattern matches the names that can be interpolated.
+var captureNamePattern = regexp.MustCompile(`^[\w-]+$`)
+
 // execCommand allows overwriting during tests.
-var execCommand = exec.Command
+var execCommand = exec.CommandContext
 
 // Command is a command passed to the shell.
 type Command struct {
@@ -28,6 +37,20 @@ type Command struct {
 
 	// Dir is the directory of the command.
 	Dir string `yaml:"dir"`
+
+	// Timeout is the maximum time the command may run before it is terminated.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// GracePeriod is how long to wait after the command is asked to terminate
+	// before it is killed.
+	GracePeriod time.Duration `yaml:"grace-period,omitempty"`
+
+	// Retry is the policy for retrying the command if it fails.
+	Retry *Retry `yaml:"retry,omitempty"`
+
+	// Capture is the name of a variable to store the command's trimmed output
+	// in. Later run items in the task can interpolate the variable.
+	Capture string `yaml:"capture,omitempty"`
 }
 
 // UnmarshalYAML allows strings to be interpreted as Do actions.
@@ -47,6 +70,12 @@ func (c *Command) UnmarshalYAML(unmarshal func(any) error) error {
 	var commandItem commandType
 	commandCandidate := marshal.UnmarshalCandidate{
 		Unmarshal: func() error { return unmarshal(&commandItem) },
+		Validate: func() error {
+			if commandItem.Capture != "" && !captureNamePattern.MatchString(commandItem.Capture) {
+				return fmt.Errorf("invalid capture name %q", commandItem.Capture)
+			}
+			return nil
+		},
 		Assign: func() {
 			*c = Command(commandItem)
 			if c.Print == "" {
@@ -71,21 +100,73 @@ func newCmd(ctx Context, script string) *exec.Cmd {
 		args = append(interpreter[1:], args...)
 	}
 
-	cmd := execCommand(path, args...)
+	cmd := execCommand(ctx.Context(), path, args...)
 	cmd.Dir = ctx.Dir()
+	setProcessGroup(cmd)
 	return cmd
 }
 
-// execCommand executes a shell command.
-func (c *Command) exec(ctx Context) error {
+// exec executes a shell command. If the command captures its output, the
+// trimmed output is returned instead of being printed.
+func (c *Command) exec(ctx Context) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout, "command", c.Print)
+	defer cancel()
+
+	cmd := c.newCmd(ctx)
+	cmd.Stdin = os.Stdin
+
+	var out strings.Builder
+	if c.Capture != "" {
+		cmd.Stdout = &out
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", withCancelCause(ctx, err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// newCmd creates an exec.Cmd for the command, which prints its output unless
+// the logger is silent.
+func (c *Command) newCmd(ctx Context) *exec.Cmd {
 	cmd := newCmd(ctx, c.Exec)
+	terminateOnCancel(ctx, cmd, gracePeriod(ctx, c))
 
 	cmd.Dir = filepath.Join(cmd.Dir, c.Dir)
-	cmd.Stdin = os.Stdin
 	if ctx.Logger.Level() > ui.LevelSilent {
 		cmd.Stdout = ctx.Logger.Stdout()
 		cmd.Stderr = ctx.Logger.Stderr()
 	}
 
-	return cmd.Run()
+	return cmd
+}
+
+// exitCode returns the exit code of a command's error. A command that could
+// not be run at all is given an exit code of -1.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// withCancelCause adds the cause of a timeout or signal to the error of a
+// command that was cancelled by one.
+func withCancelCause(ctx Context, err error) error {
+	cause := context.Cause(ctx.Context())
+
+	var timeoutErr *timeoutError
+	var sigErr *SignalError
+	if errors.As(cause, &timeoutErr) || errors.As(cause, &sigErr) {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+
+	return err
 }
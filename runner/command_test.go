@@ -1,12 +1,15 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rliebz/ghost"
@@ -55,6 +58,25 @@ func TestCommand_UnmarshalYAML(t *testing.T) {
 				Dir:   "dirvalue",
 			},
 		},
+		{
+			"timeout",
+			`{exec: example, timeout: 1m30s, grace-period: 5s}`,
+			Command{
+				Exec:        "example",
+				Print:       "example",
+				Timeout:     90 * time.Second,
+				GracePeriod: 5 * time.Second,
+			},
+		},
+		{
+			"capture",
+			`{exec: git rev-parse HEAD, capture: commit-sha}`,
+			Command{
+				Exec:    "git rev-parse HEAD",
+				Print:   "git rev-parse HEAD",
+				Capture: "commit-sha",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +92,27 @@ func TestCommand_UnmarshalYAML(t *testing.T) {
 	}
 }
 
+func TestCommand_UnmarshalYAML_invalid_capture(t *testing.T) {
+	g := ghost.New(t)
+
+	var got Command
+	err := yaml.UnmarshalStrict([]byte(`{exec: example, capture: "${foo}"}`), &got)
+	g.Should(be.ErrorEqual(err, `invalid capture name "${foo}"`))
+}
+
+func TestCommand_exec_capture(t *testing.T) {
+	g := ghost.New(t)
+
+	command := Command{
+		Exec:    "echo '  hello  '",
+		Capture: "greeting",
+	}
+
+	out, err := command.exec(Context{Logger: ui.Noop()})
+	g.NoError(err)
+	g.Should(be.Equal(out, "hello"))
+}
+
 func TestCommand_exec(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -103,11 +146,11 @@ func TestCommand_exec(t *testing.T) {
 				Dir:  "..",
 			}
 
-			t.Cleanup(func() { execCommand = exec.Command })
-			execCommand = func(name string, arg ...string) *exec.Cmd {
+			t.Cleanup(func() { execCommand = exec.CommandContext })
+			execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
 				cs := []string{"-test.run=TestCommand_exec_helper", "--", name}
 				cs = append(cs, arg...)
-				cmd := exec.Command(os.Args[0], cs...)
+				cmd := exec.CommandContext(ctx, os.Args[0], cs...)
 				cmd.Env = []string{
 					"TUSK_TEST_EXEC_COMMAND=1",
 					"TUSK_TEST_COMMAND_ARGS=" + strings.Join(tt.want, ","),
@@ -121,7 +164,7 @@ func TestCommand_exec(t *testing.T) {
 				Interpreter: tt.interpreter,
 			}
 
-			err = command.exec(ctx)
+			_, err = command.exec(ctx)
 			g.NoError(err)
 		})
 	}
@@ -170,3 +213,13 @@ func TestCommand_exec_helper(*testing.T) {
 		fail(fmt.Sprintf("want working dir %s, got %s", wantDir, dir))
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	g := ghost.New(t)
+
+	g.Should(be.Equal(exitCode(nil), 0))
+	g.Should(be.Equal(exitCode(errors.New("oops")), -1))
+
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	g.Should(be.Equal(exitCode(fmt.Errorf("wrapped: %w", err)), 3))
+}
@@ -1,6 +1,10 @@
 package runner
 
-import "github.com/rliebz/tusk/marshal"
+import (
+	"sync"
+
+	"github.com/rliebz/tusk/marshal"
+)
 
 // Config is a struct representing the format for configuration settings.
 type Config struct {
@@ -16,6 +20,13 @@ type Config struct {
 
 	Tasks   map[string]*Task `yaml:"tasks"`
 	Options Options          `yaml:"options,omitempty"`
+
+	// Cache configures when task cache entries of the project are pruned.
+	Cache CacheConfig `yaml:"cache,omitempty"`
+
+	// optionsMu guards the evaluation state of options, which are evaluated
+	// lazily.
+	optionsMu sync.Mutex
 }
 
 // UnmarshalYAML unmarshals and assigns names to options and tasks.
@@ -29,5 +40,5 @@ func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
 		t.Name = name
 	}
 
-	return nil
+	return c.Cache.isValid()
 }
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"path/filepath"
 	"slices"
 
@@ -19,7 +20,51 @@ type Context struct {
 	// Interpreter specifies how a command is meant to be executed.
 	Interpreter []string
 
+	// DryRun reports what would be executed without running any commands.
+	DryRun bool
+
+	// FullHash hashes every source and target file, rather than reusing the
+	// hashes of files whose stats have not changed.
+	FullHash bool
+
+	// ArtifactCache is where the targets of tasks are stored once they run, so
+	// they can be restored rather than run again. It is either a directory or
+	// an HTTP URL, and is not used if empty.
+	ArtifactCache string
+
+	// LogDir is a directory that the output of each task is copied to, in a
+	// file named after the task. It is not used if empty.
+	LogDir string
+
 	taskStack []*Task
+
+	// cancelCtx governs the cancellation of running commands.
+	cancelCtx context.Context
+
+	// executions is shared by every task in a single invocation.
+	executions *executions
+
+	// logs is the task log files shared by every task in a single invocation.
+	logs *logFiles
+
+	// background is the background commands started by the current task.
+	background *backgroundGroup
+}
+
+// Context returns the context.Context that governs the cancellation of
+// running commands.
+func (c Context) Context() context.Context {
+	if c.cancelCtx == nil {
+		return context.Background()
+	}
+	return c.cancelCtx
+}
+
+// WithContext returns a copy of the context that will stop running commands
+// once ctx is done.
+func (c Context) WithContext(ctx context.Context) Context {
+	c.cancelCtx = ctx
+	return c
 }
 
 // Dir is the directory that defines the config file, which is the relative
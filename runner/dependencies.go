@@ -2,6 +2,7 @@ package runner
 
 import (
 	"encoding/json"
+	"slices"
 
 	"github.com/rliebz/tusk/marshal"
 )
@@ -34,9 +35,59 @@ func FindAllOptions(t *Task, cfg *Config) ([]*Option, error) {
 		return nil, err
 	}
 
+	if err := checkOptionCycles(required, candidates); err != nil {
+		return nil, err
+	}
+
 	return required, nil
 }
 
+// checkOptionCycles returns an error if any option depends on itself, either
+// directly or through other options.
+func checkOptionCycles(options []*Option, candidates map[string]*Option) error {
+	checked := make(map[*Option]bool)
+
+	var check func(opt *Option, path []string) error
+	check = func(opt *Option, path []string) error {
+		if i := slices.Index(path, opt.Name); i >= 0 {
+			return newCycleError("option", append(slices.Clone(path[i:]), opt.Name))
+		}
+		if checked[opt] {
+			return nil
+		}
+
+		names, err := getDependencies(opt)
+		if err != nil {
+			return err
+		}
+
+		path = append(slices.Clip(path), opt.Name)
+		for _, name := range names {
+			// References to an option's own name do not recurse, since the
+			// option's value is not yet known while it is being evaluated.
+			dep, ok := candidates[name]
+			if !ok || dep == opt {
+				continue
+			}
+
+			if err := check(dep, path); err != nil {
+				return err
+			}
+		}
+
+		checked[opt] = true
+		return nil
+	}
+
+	for _, opt := range options {
+		if err := check(opt, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func findRequiredOptionsRecursively(
 	entry []string,
 	candidates map[string]*Option,
@@ -91,15 +142,24 @@ type dependencyGetter interface {
 }
 
 func getDependencies(item dependencyGetter) ([]string, error) {
-	// TODO: Remove json dependency by implementing stringer interface
-	// json is used to print computed fields that should not be yaml parseable
-	marshaled, err := json.Marshal(item)
+	names, err := getReferences(item)
 	if err != nil {
 		return nil, err
 	}
 
-	names := marshal.FindPotentialVariables(marshaled)
 	names = append(names, item.Dependencies()...)
 
 	return names, nil
 }
+
+// getReferences returns the names of the variables an item may interpolate.
+func getReferences(item any) ([]string, error) {
+	// TODO: Remove json dependency by implementing stringer interface
+	// json is used to print computed fields that should not be yaml parseable
+	marshaled, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshal.FindPotentialVariables(marshaled), nil
+}
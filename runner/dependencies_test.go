@@ -222,6 +222,68 @@ func TestFindAllOptions(t *testing.T) {
 	}
 }
 
+func TestFindAllOptions_cycles(t *testing.T) {
+	tests := []struct {
+		name        string
+		taskOptions []*Option
+		cfgOptions  []*Option
+		wantErr     string
+	}{
+		{
+			name: "direct cycle",
+			taskOptions: []*Option{
+				createOption(
+					withOptionName("foo"),
+					withOptionDependency("bar"),
+				),
+			},
+			cfgOptions: []*Option{
+				createOption(
+					withOptionName("bar"),
+					withOptionDependency("foo"),
+				),
+			},
+			wantErr: "option cycle detected: foo -> bar -> foo",
+		},
+		{
+			name: "indirect cycle",
+			cfgOptions: []*Option{
+				createOption(
+					withOptionName("one"),
+					withOptionDependency("two"),
+				),
+				createOption(
+					withOptionName("two"),
+					withOptionWhenDependency("three"),
+				),
+				createOption(
+					withOptionName("three"),
+					withOptionDependency("one"),
+				),
+			},
+			taskOptions: []*Option{
+				createOption(
+					withOptionName("foo"),
+					withOptionDependency("one"),
+				),
+			},
+			wantErr: "option cycle detected: one -> two -> three -> one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			tsk := Task{Options: tt.taskOptions}
+			cfg := Config{Options: tt.cfgOptions}
+
+			_, err := FindAllOptions(&tsk, &cfg)
+			g.Should(be.ErrorEqual(err, tt.wantErr))
+		})
+	}
+}
+
 func assertOptionsEqualUnordered(t *testing.T, a, b []*Option) {
 	t.Helper()
 
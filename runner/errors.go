@@ -3,6 +3,7 @@ package runner
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // IsFailedCondition checks if an error was because of a failed condition.
@@ -65,3 +66,9 @@ func newUnspecifiedError(clauseName string) error {
 	formatted := fmt.Sprintf("clause %q is not defined", clauseName)
 	return &unspecifiedClauseError{formatted}
 }
+
+// newCycleError returns an error describing a dependency cycle, where the path
+// starts and ends with the same item.
+func newCycleError(kind string, path []string) error {
+	return fmt.Errorf("%s cycle detected: %s", kind, strings.Join(path, " -> "))
+}
@@ -0,0 +1,76 @@
+package runner
+
+import "sync"
+
+// executions tracks the tasks executed during a single invocation, so that
+// each task is only executed once.
+type executions struct {
+	mu      sync.Mutex
+	results map[*Task]*execution
+}
+
+// execution is the result of a single task execution.
+type execution struct {
+	done chan struct{}
+	err  error
+}
+
+// start registers the execution of a task. If the task has not started yet,
+// first is true and finish must be called on the execution once the task has
+// completed.
+func (e *executions) start(t *Task) (exec *execution, first bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if exec, ok := e.results[t]; ok {
+		return exec, false
+	}
+
+	if e.results == nil {
+		e.results = make(map[*Task]*execution)
+	}
+
+	exec = &execution{done: make(chan struct{})}
+	e.results[t] = exec
+	return exec, true
+}
+
+// forget removes the finished executions of the tasks, so that each is
+// executed again the next time it starts. Executions still in progress are
+// kept.
+func (e *executions) forget(tasks []*Task) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, t := range tasks {
+		if exec, ok := e.results[t]; ok && exec.finished() {
+			delete(e.results, t)
+		}
+	}
+}
+
+// finish records the result of the execution.
+func (e *execution) finish(err error) {
+	e.err = err
+	close(e.done)
+}
+
+// finished reports whether the execution has finished.
+func (e *execution) finished() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the execution has finished and returns its result.
+func (e *execution) wait() error {
+	<-e.done
+	return e.err
+}
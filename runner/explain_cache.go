@@ -0,0 +1,219 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExplainCache prints why a task is or is not up to date, without running it.
+//
+// The current inputs of the task are compared with the cache entry for those
+// inputs, or if there is none, with the most recently cached run of the task.
+func (t *Task) ExplainCache(ctx Context, w io.Writer) error {
+	ctx = ctx.WithTask(t)
+
+	fmt.Fprintf(w, "Task: %s\n", t.Name)
+	if !t.isCacheable() {
+		fmt.Fprintln(w, "The task has no source and target, so it is never cached.")
+		return nil
+	}
+
+	stats, err := t.loadStatCache(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := t.taskInputs(ctx, stats)
+	if err != nil {
+		return err
+	}
+
+	cachePath, err := t.taskInputCachePath(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	targets, err := t.outputManifest(ctx, stats)
+	if err != nil {
+		return err
+	}
+
+	entry, err := readCacheEntry(cachePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Input checksum: %s\n", filepath.Base(cachePath))
+	if entry.Target != "" {
+		explainTargets(w, targets, entry)
+		return nil
+	}
+
+	fmt.Fprintln(w, "Target checksum: missing, since no run with these inputs is cached")
+
+	latestPath, latest, err := latestCacheEntry(filepath.Dir(cachePath))
+	if err != nil {
+		return err
+	}
+	if latestPath == "" {
+		fmt.Fprintln(w, "Cached input checksum: none, since the task has not been cached")
+		return nil
+	}
+
+	fmt.Fprintf(w, "Cached input checksum: %s\n", filepath.Base(latestPath))
+	explainInputs(w, inputs, latest.Inputs)
+	return nil
+}
+
+// explainTargets prints whether the targets of a task match a cache entry.
+func explainTargets(w io.Writer, targets manifest, entry cacheEntry) {
+	switch {
+	case targets == nil:
+		fmt.Fprintln(w, "Target checksum: stale, since some targets do not exist")
+	case targets.checksum() == entry.Target:
+		fmt.Fprintln(w, "Target checksum: up to date")
+	default:
+		fmt.Fprintln(w, "Target checksum: stale, since the targets have changed")
+	}
+
+	explainManifest(w, "Targets", targets, entry.Targets)
+}
+
+// explainInputs prints the differences between the inputs of a task and the
+// inputs of a cached run.
+func explainInputs(w io.Writer, current taskInputs, cached taskInputs) {
+	explainManifest(w, "Sources", current.Sources, cached.Sources)
+
+	if current.Definition != cached.Definition {
+		fmt.Fprintln(w, "Definition: changed")
+	}
+
+	if !slices.Equal(current.Interpreter, cached.Interpreter) {
+		fmt.Fprintln(w, "Interpreter: changed")
+	}
+
+	explainValues(w, "Values", current.Vars, cached.Vars)
+	explainValues(w, "Environment", current.Env, cached.Env)
+}
+
+// explainManifest prints the checksum of each file, along with whether it has
+// changed, been added, or been removed since it was cached.
+func explainManifest(w io.Writer, title string, current manifest, cached manifest) {
+	fmt.Fprintf(w, "%s:\n", title)
+
+	for _, path := range unionKeys(current, cached) {
+		sum, ok := current[path]
+		cachedSum, wasCached := cached[path]
+
+		var status string
+		switch {
+		case !ok:
+			status, sum = "removed", cachedSum
+		case !wasCached:
+			status = "added"
+		case sum != cachedSum:
+			status = "changed"
+		default:
+			status = "unchanged"
+		}
+
+		fmt.Fprintf(w, "  %-9s  %s  %s\n", status, sum, path)
+	}
+}
+
+// explainValues prints the names of values that have changed. The values are
+// compared by their checksums, since the values themselves are not cached.
+func explainValues(w io.Writer, title string, current, cached map[string]string) {
+	var changed []string
+	for _, name := range unionKeys(current, cached) {
+		value, ok := current[name]
+		cachedValue, wasCached := cached[name]
+		if ok != wasCached || value != cachedValue {
+			changed = append(changed, name)
+		}
+	}
+
+	if len(changed) > 0 {
+		fmt.Fprintf(w, "%s: changed %s\n", title, strings.Join(changed, ", "))
+	}
+}
+
+// unionKeys returns the sorted keys that are in either map.
+func unionKeys[M ~map[string]string](a, b M) []string {
+	keys := slices.Collect(maps.Keys(a))
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// latestCacheEntry returns the path and content of the most recently written
+// entry in a task's cache directory, or an empty path if there is none.
+func latestCacheEntry(cacheDir string) (string, cacheEntry, error) {
+	files, err := cacheEntryFiles(cacheDir)
+	if err != nil {
+		return "", cacheEntry{}, err
+	}
+
+	for _, f := range files {
+		entry, err := readCacheEntry(f.path)
+		if err != nil {
+			return "", cacheEntry{}, err
+		}
+
+		if entry.Target != "" {
+			return f.path, entry, nil
+		}
+	}
+
+	return "", cacheEntry{}, nil
+}
+
+// cacheFile is a file in the cache.
+type cacheFile struct {
+	path string
+	info fs.FileInfo
+}
+
+// cacheEntryFiles returns the entries in a task's cache directory, most
+// recently written first.
+func cacheEntryFiles(cacheDir string) ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]cacheFile, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if !d.Type().IsRegular() || d.Name() == statCacheFile {
+			continue
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, cacheFile{path: filepath.Join(cacheDir, d.Name()), info: info})
+	}
+
+	slices.SortFunc(files, func(a, b cacheFile) int {
+		return b.info.ModTime().Compare(a.info.ModTime())
+	})
+
+	return files, nil
+}
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+const explainCfgText = `
+tasks:
+  build:
+    options:
+      release:
+        type: bool
+    source: src/*
+    target: out.txt
+    cache-env: TUSK_TEST_TARGET
+    run: cat src/* > out.txt && echo ${release} >> out.txt
+  lint:
+    run: echo lint
+`
+
+// parseExplainTask parses a task for explaining its cache.
+func parseExplainTask(t *testing.T, taskName string, flags map[string]string) *Task {
+	t.Helper()
+	g := ghost.New(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgPath:  "tusk.yml",
+		CfgText:  []byte(explainCfgText),
+		Flags:    flags,
+		TaskName: taskName,
+	})
+	g.NoError(err)
+
+	return cfg.Tasks[taskName]
+}
+
+// explainCache returns the explanation for why a task is or is not cached.
+func explainCache(t *testing.T, task *Task) string {
+	t.Helper()
+	g := ghost.New(t)
+
+	var buf bytes.Buffer
+	err := task.ExplainCache(Context{CfgPath: "tusk.yml", Logger: ui.Noop()}, &buf)
+	g.NoError(err)
+
+	return buf.String()
+}
+
+func setupExplainCache(t *testing.T) {
+	t.Helper()
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	err := os.Mkdir("src", 0o700)
+	g.NoError(err)
+
+	for _, name := range []string{"src/a.txt", "src/b.txt"} {
+		err = os.WriteFile(name, []byte(name), 0o600)
+		g.NoError(err)
+	}
+}
+
+func TestTask_ExplainCache_inputs_changed(t *testing.T) {
+	g := ghost.New(t)
+
+	setupExplainCache(t)
+
+	g.Should(be.StringContaining(
+		explainCache(t, parseExplainTask(t, "build", nil)),
+		"Cached input checksum: none, since the task has not been cached\n",
+	))
+
+	err := parseExplainTask(t, "build", nil).Execute(Context{
+		CfgPath: "tusk.yml",
+		Logger:  ui.Noop(),
+	})
+	g.NoError(err)
+
+	err = os.WriteFile("src/a.txt", []byte("changed"), 0o600)
+	g.NoError(err)
+	err = os.Remove("src/b.txt")
+	g.NoError(err)
+	err = os.WriteFile("src/c.txt", []byte("added"), 0o600)
+	g.NoError(err)
+	t.Setenv("TUSK_TEST_TARGET", "linux")
+
+	got := explainCache(t, parseExplainTask(t, "build", map[string]string{"release": "true"}))
+
+	const sum = "[0-9a-f]{64}"
+	for _, line := range []string{
+		"Task: build",
+		"Input checksum: " + sum,
+		"Target checksum: missing, since no run with these inputs is cached",
+		"Cached input checksum: " + sum,
+		"Sources:",
+		"  changed    " + sum + `  src/a\.txt`,
+		"  removed    " + sum + `  src/b\.txt`,
+		"  added      " + sum + `  src/c\.txt`,
+		"Values: changed release",
+		"Environment: changed TUSK_TEST_TARGET",
+	} {
+		g.Should(be.StringMatching(got, "(?m)^"+line+"$"))
+	}
+	g.Should(be.Not(be.StringContaining(got, "Definition: changed")))
+}
+
+func TestTask_ExplainCache_targets(t *testing.T) {
+	g := ghost.New(t)
+
+	setupExplainCache(t)
+
+	err := parseExplainTask(t, "build", nil).Execute(Context{
+		CfgPath: "tusk.yml",
+		Logger:  ui.Noop(),
+	})
+	g.NoError(err)
+
+	got := explainCache(t, parseExplainTask(t, "build", nil))
+	g.Should(be.StringContaining(got, "Target checksum: up to date\n"))
+	g.Should(be.StringMatching(got, `(?m)^  unchanged  [0-9a-f]{64}  out\.txt$`))
+
+	err = os.WriteFile("out.txt", []byte("changed"), 0o600)
+	g.NoError(err)
+
+	got = explainCache(t, parseExplainTask(t, "build", nil))
+	g.Should(be.StringContaining(got, "Target checksum: stale, since the targets have changed\n"))
+	g.Should(be.StringMatching(got, `(?m)^  changed    [0-9a-f]{64}  out\.txt$`))
+
+	err = os.Remove("out.txt")
+	g.NoError(err)
+
+	got = explainCache(t, parseExplainTask(t, "build", nil))
+	g.Should(be.StringContaining(got, "Target checksum: stale, since some targets do not exist\n"))
+	g.Should(be.StringMatching(got, `(?m)^  removed    [0-9a-f]{64}  out\.txt$`))
+}
+
+func TestTask_ExplainCache_not_cacheable(t *testing.T) {
+	g := ghost.New(t)
+
+	setupExplainCache(t)
+
+	got := explainCache(t, parseExplainTask(t, "lint", nil))
+	g.Should(be.Equal(
+		got,
+		"Task: lint\nThe task has no source and target, so it is never cached.\n",
+	))
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// FailuresError is returned when a run has continued past failures.
+type FailuresError struct {
+	Failures []ui.Failure
+
+	// errs holds the error that caused each failure.
+	errs []error
+}
+
+func (e *FailuresError) Error() string {
+	if len(e.Failures) == 1 {
+		return e.Failures[0].Message
+	}
+	return fmt.Sprintf("%d failures", len(e.Failures))
+}
+
+func (e *FailuresError) Unwrap() []error {
+	return e.errs
+}
+
+// commandError is the error of a failed command.
+type commandError struct {
+	command string
+	tasks   []string
+	err     error
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// failures collects the failures of a run that continues on error. It is safe
+// for concurrent use.
+type failures struct {
+	mu   sync.Mutex
+	list []ui.Failure
+	errs []error
+}
+
+// add collects the failures that caused an error.
+func (f *failures) add(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var failed *FailuresError
+	if errors.As(err, &failed) {
+		for i, cause := range failed.errs {
+			f.append(failed.Failures[i], cause)
+		}
+		return
+	}
+
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		f.append(ui.Failure{
+			Command:  cmdErr.command,
+			Tasks:    cmdErr.tasks,
+			ExitCode: exitCode(cmdErr.err),
+			Message:  err.Error(),
+		}, cmdErr)
+		return
+	}
+
+	f.append(ui.Failure{
+		ExitCode: exitCode(err),
+		Message:  err.Error(),
+	}, err)
+}
+
+// append adds a failure, unless it has already been collected. This happens
+// when a failed sub-task is referenced more than once, as each reference
+// returns the same error.
+func (f *failures) append(failure ui.Failure, err error) {
+	if cmdErr, ok := err.(*commandError); ok && slices.Contains(f.errs, error(cmdErr)) {
+		return
+	}
+
+	f.list = append(f.list, failure)
+	f.errs = append(f.errs, err)
+}
+
+// err returns the collected failures as an error, or nil if there are none.
+func (f *failures) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.list) == 0 {
+		return nil
+	}
+
+	return &FailuresError{
+		Failures: slices.Clone(f.list),
+		errs:     slices.Clone(f.errs),
+	}
+}
+
+// with returns an error that stops a run. Any failures collected so far are
+// included, so that they are still reported.
+func (f *failures) with(err error) error {
+	f.mu.Lock()
+	empty := len(f.list) == 0
+	f.mu.Unlock()
+
+	if empty {
+		return err
+	}
+
+	f.add(err)
+	return f.err()
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func executeTask(t *testing.T, cfgText, taskName string) error {
+	t.Helper()
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  []byte(cfgText),
+		TaskName: taskName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg.Tasks[taskName].Execute(Context{Logger: ui.Noop()})
+}
+
+func TestTask_Execute_continue_on_error(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  lint:
+    continue-on-error: true
+    run:
+      - exit 1
+      - touch ran.txt
+      - [exit 2, exit 3]
+    finally: touch finally.txt
+`, "lint")
+
+	var failuresErr *FailuresError
+	g.Must(be.True(errors.As(err, &failuresErr)))
+	g.Should(be.DeepEqual(failuresErr.Failures, []ui.Failure{
+		{Command: "exit 1", Tasks: []string{"lint"}, ExitCode: 1, Message: "exit status 1"},
+		{Command: "exit 2", Tasks: []string{"lint"}, ExitCode: 2, Message: "exit status 2"},
+		{Command: "exit 3", Tasks: []string{"lint"}, ExitCode: 3, Message: "exit status 3"},
+	}))
+	g.Should(be.ErrorEqual(err, "3 failures"))
+
+	for _, name := range []string{"ran.txt", "finally.txt"} {
+		_, err = os.Stat(name)
+		g.NoError(err)
+	}
+}
+
+func TestTask_Execute_continue_on_error_run_item(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  lint:
+    run:
+      - continue-on-error: true
+        command: [exit 1, exit 2]
+      - touch ran.txt
+      - exit 3
+      - touch skipped.txt
+`, "lint")
+
+	var failuresErr *FailuresError
+	g.Must(be.True(errors.As(err, &failuresErr)))
+	g.Should(be.DeepEqual(failuresErr.Failures, []ui.Failure{
+		{Command: "exit 1", Tasks: []string{"lint"}, ExitCode: 1, Message: "exit status 1"},
+		{Command: "exit 2", Tasks: []string{"lint"}, ExitCode: 2, Message: "exit status 2"},
+		{Command: "exit 3", Tasks: []string{"lint"}, ExitCode: 3, Message: "exit status 3"},
+	}))
+
+	_, err = os.Stat("ran.txt")
+	g.NoError(err)
+
+	_, err = os.Stat("skipped.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestTask_Execute_continue_on_error_parallel(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  lint:
+    run:
+      continue-on-error: true
+      parallel: true
+      command: [exit 1, sleep 0.1 && touch ran.txt]
+`, "lint")
+
+	var failuresErr *FailuresError
+	g.Must(be.True(errors.As(err, &failuresErr)))
+	g.Should(be.SliceLen(failuresErr.Failures, 1))
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+
+	_, err = os.Stat("ran.txt")
+	g.NoError(err)
+}
+
+func TestTask_Execute_continue_on_error_sub_tasks(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  fail:
+    run: exit 1
+  lint:
+    continue-on-error: true
+    run:
+      - task: fail
+      - task: fail
+      - touch ran.txt
+`, "lint")
+
+	var failuresErr *FailuresError
+	g.Must(be.True(errors.As(err, &failuresErr)))
+	g.Should(be.DeepEqual(failuresErr.Failures, []ui.Failure{
+		{Command: "exit 1", Tasks: []string{"lint", "fail"}, ExitCode: 1, Message: "exit status 1"},
+	}))
+
+	_, err = os.Stat("ran.txt")
+	g.NoError(err)
+}
+
+func TestTask_Execute_without_continue_on_error(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  lint:
+    run:
+      - exit 1
+      - touch skipped.txt
+`, "lint")
+
+	var failuresErr *FailuresError
+	g.Should(be.False(errors.As(err, &failuresErr)))
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+
+	_, err = os.Stat("skipped.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// gitignore matches files against the rules in the .gitignore files of a
+// directory tree. Only the .gitignore files within the tree are read, so rules
+// from parent directories and global excludes do not apply.
+//
+// A nil gitignore is valid and ignores nothing. A gitignore is not safe for
+// concurrent use.
+type gitignore struct {
+	fsys fs.FS
+
+	// rules holds the rules of each directory's .gitignore file.
+	rules map[string][]gitignoreRule
+
+	// dirs holds whether each directory is ignored.
+	dirs map[string]bool
+}
+
+// gitignoreRule is a single pattern from a .gitignore file.
+type gitignoreRule struct {
+	pattern string
+
+	// negate re-includes files that an earlier rule ignored.
+	negate bool
+
+	// dirOnly only matches directories.
+	dirOnly bool
+
+	// anchored matches the path relative to the .gitignore file, rather than
+	// the name of a file or directory at any depth.
+	anchored bool
+}
+
+func newGitignore(fsys fs.FS) *gitignore {
+	return &gitignore{
+		fsys:  fsys,
+		rules: make(map[string][]gitignoreRule),
+		dirs:  make(map[string]bool),
+	}
+}
+
+// isIgnored reports whether a file is ignored, either by a rule or because a
+// directory containing it is.
+func (g *gitignore) isIgnored(name string) (bool, error) {
+	if g == nil {
+		return false, nil
+	}
+
+	return g.isIgnoredPath(name, false)
+}
+
+func (g *gitignore) isIgnoredPath(name string, isDir bool) (bool, error) {
+	// As with git, files cannot be re-included if their directory is ignored.
+	if parent := path.Dir(name); parent != "." {
+		ignored, err := g.isDirIgnored(parent)
+		if err != nil || ignored {
+			return ignored, err
+		}
+	}
+
+	if isDir && path.Base(name) == ".git" {
+		return true, nil
+	}
+
+	return g.matches(name, isDir)
+}
+
+func (g *gitignore) isDirIgnored(dir string) (bool, error) {
+	if ignored, ok := g.dirs[dir]; ok {
+		return ignored, nil
+	}
+
+	ignored, err := g.isIgnoredPath(dir, true)
+	if err != nil {
+		return false, err
+	}
+
+	g.dirs[dir] = ignored
+	return ignored, nil
+}
+
+// matches reports whether the last rule that matches a path ignores it. Rules
+// in deeper directories are checked last, so they take precedence.
+func (g *gitignore) matches(name string, isDir bool) (bool, error) {
+	ignored := false
+	for _, dir := range parentDirs(name) {
+		rules, err := g.loadRules(dir)
+		if err != nil {
+			return false, err
+		}
+
+		rel := name
+		if dir != "." {
+			rel = strings.TrimPrefix(name, dir+"/")
+		}
+
+		ignored = matchRules(rules, rel, isDir, ignored)
+	}
+
+	return ignored, nil
+}
+
+// loadRules returns the rules of the .gitignore file in a directory.
+func (g *gitignore) loadRules(dir string) ([]gitignoreRule, error) {
+	if rules, ok := g.rules[dir]; ok {
+		return rules, nil
+	}
+
+	data, err := fs.ReadFile(g.fsys, path.Join(dir, ".gitignore"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	rules := parseGitignore(string(data))
+	g.rules[dir] = rules
+	return rules, nil
+}
+
+// parseGitignore returns the rules in the contents of a .gitignore file.
+func parseGitignore(data string) []gitignoreRule {
+	var rules []gitignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule gitignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else {
+			// A leading backslash escapes a literal "#" or "!".
+			line = strings.TrimPrefix(line, `\`)
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// matchRules applies rules in order to a path, starting from whether it is
+// already ignored.
+func matchRules(rules []gitignoreRule, rel string, isDir bool, ignored bool) bool {
+	for _, rule := range rules {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+func (r gitignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if !r.anchored {
+		rel = path.Base(rel)
+	}
+
+	ok, err := doublestar.Match(r.pattern, rel)
+	return err == nil && ok
+}
+
+// parentDirs returns the directories containing a path, starting with ".".
+func parentDirs(name string) []string {
+	dirs := []string{"."}
+	for i, c := range name {
+		if c == '/' {
+			dirs = append(dirs, name[:i])
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestGitignore_isIgnored(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte(`
+# comments and blank lines are skipped
+*.log
+!keep.log
+build/
+/root.txt
+\#literal
+docs/**/*.tmp
+`)},
+		"nested/.gitignore": {Data: []byte("!*.log\nlocal/\n")},
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a.go", false},
+		{"debug.log", true},
+		{"keep.log", false},
+		{"sub/debug.log", true},
+		{"nested/debug.log", false},
+		{"build/out", true},
+		{"sub/build/out", true},
+		{"build", false},
+		{"root.txt", true},
+		{"sub/root.txt", false},
+		{"#literal", true},
+		{"docs/a/b/c.tmp", true},
+		{"docs/c.txt", false},
+		{"nested/local/a.go", true},
+		{".git/HEAD", true},
+		{"sub/.git/config", true},
+		{".github/workflows/ci.yml", false},
+	}
+
+	ignore := newGitignore(fsys)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			got, err := ignore.isIgnored(tt.name)
+			g.NoError(err)
+			g.Should(be.Equal(got, tt.want))
+		})
+	}
+}
+
+func TestGitignore_isIgnored_nil(t *testing.T) {
+	g := ghost.New(t)
+
+	var ignore *gitignore
+	got, err := ignore.isIgnored("debug.log")
+	g.NoError(err)
+	g.Should(be.False(got))
+}
@@ -20,6 +20,9 @@ type Option struct {
 	Required bool
 	Rewrite  string
 
+	// Secret hides the value as it is typed when it is prompted for.
+	Secret bool `yaml:"secret,omitempty"`
+
 	// Used to determine value
 	Environment   string
 	DefaultValues marshal.Slice[Value] `yaml:"default"`
@@ -27,6 +30,14 @@ type Option struct {
 	// Computed members not specified in yaml file
 	cacheValue string `yaml:"-"`
 	isCacheSet bool   `yaml:"-"`
+
+	// isPlaceholder reports whether the value depends on a command that was
+	// not run during a dry run, so that it is not the value a real run uses.
+	isPlaceholder bool `yaml:"-"`
+
+	// evaluating is closed once the option being evaluated for a task has a
+	// value, so that other tasks using the option can wait for it.
+	evaluating chan struct{} `yaml:"-"`
 }
 
 // Equal provides a method of checking option equality for testing purposes only.
@@ -135,21 +146,43 @@ func (o *Option) getValue(ctx Context, vars map[string]string) (string, error) {
 		return o.cacheValue, nil
 	}
 
+	value, found, err := o.getSpecifiedValue()
+	if err != nil || found {
+		return value, err
+	}
+
+	return o.getDefaultValue(ctx, vars)
+}
+
+// validateSpecified checks the value passed for an option without evaluating
+// its default, so that invalid or missing values are reported immediately.
+func (o *Option) validateSpecified() error {
+	if o.isCacheSet {
+		return nil
+	}
+
+	_, _, err := o.getSpecifiedValue()
+	return err
+}
+
+// getSpecifiedValue returns the value passed for the option or set in its
+// environment variable, if any.
+func (o *Option) getSpecifiedValue() (value string, found bool, err error) {
 	if !o.Private {
 		if value, found := o.getSpecified(); found {
 			if err := o.validatePassed(value); err != nil {
-				return "", err
+				return "", false, err
 			}
 
-			return value, nil
+			return value, true, nil
 		}
 	}
 
 	if o.Required {
-		return "", fmt.Errorf("no value passed for required option: %s", o.Name)
+		return "", false, fmt.Errorf("no value passed for required option: %s", o.Name)
 	}
 
-	return o.getDefaultValue(ctx, vars)
+	return "", false, nil
 }
 
 func (o *Option) getSpecified() (value string, found bool) {
@@ -200,6 +233,10 @@ func (o *Option) getDefaultValue(ctx Context, vars map[string]string) (string, e
 			return "", fmt.Errorf("could not compute value for option %q: %w", o.Name, err)
 		}
 
+		if ctx.DryRun && (candidate.Command != "" || candidate.When.hasCommand()) {
+			o.isPlaceholder = true
+		}
+
 		return value, nil
 	}
 
@@ -219,6 +256,24 @@ func (o *Option) cache(value string) {
 	o.cacheValue = value
 }
 
+// interpolatedValue returns the value that is interpolated for an option.
+// Boolean options with a rewrite value are replaced by it when true, and by
+// nothing when false.
+func (o *Option) interpolatedValue(value string) string {
+	if !o.isBoolean() || o.Rewrite == "" {
+		return value
+	}
+
+	switch value {
+	case "true":
+		return o.Rewrite
+	case "false":
+		return ""
+	default:
+		return value
+	}
+}
+
 // Options represents an ordered set of options as specified in the config.
 type Options []*Option
 
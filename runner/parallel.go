@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// parallelUnlimited is the value of a Parallel that is not limited.
+const parallelUnlimited Parallel = -1
+
+// Parallel is the number of actions in a run item that may execute at once.
+//
+// The zero value executes actions sequentially. In yaml, true executes all
+// actions concurrently, while a positive integer limits the concurrency.
+type Parallel int
+
+// UnmarshalYAML allows booleans or integers to represent concurrency.
+func (p *Parallel) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+	boolCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&enabled) },
+		Assign: func() {
+			*p = 0
+			if enabled {
+				*p = parallelUnlimited
+			}
+		},
+	}
+
+	var limit int
+	intCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&limit) },
+		Validate: func() error {
+			if limit < 1 {
+				return fmt.Errorf("parallel limit must be positive, got %d", limit)
+			}
+			return nil
+		},
+		Assign: func() { *p = Parallel(limit) },
+	}
+
+	return marshal.UnmarshalOneOf(boolCandidate, intCandidate)
+}
+
+// MarshalYAML marshals unlimited concurrency as a boolean.
+func (p Parallel) MarshalYAML() (any, error) {
+	if p == parallelUnlimited {
+		return true, nil
+	}
+	return int(p), nil
+}
+
+// runParallel calls each function concurrently up to the configured limit.
+//
+// The first error returned cancels the context passed to the remaining
+// functions, and functions that have not yet started are not called.
+func (p Parallel) runParallel(ctx Context, funcs []func(Context) error) error {
+	g, gctx := errgroup.WithContext(ctx.Context())
+	if p != parallelUnlimited {
+		g.SetLimit(int(p))
+	}
+
+	ctx = ctx.WithContext(gctx)
+	for _, f := range funcs {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			return f(ctx)
+		})
+	}
+
+	return g.Wait()
+}
+
+// commandLabel returns a short, single-line label for a command.
+func commandLabel(c *Command) string {
+	const maxLength = 24
+
+	label, _, multiline := strings.Cut(strings.TrimSpace(c.Print), "\n")
+	if runes := []rune(label); len(runes) > maxLength {
+		label = string(runes[:maxLength-1]) + "…"
+		multiline = false
+	}
+	if multiline {
+		label += " …"
+	}
+
+	return label
+}
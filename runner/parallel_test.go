@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestParallel_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Parallel
+		wantErr string
+	}{
+		{input: `true`, want: parallelUnlimited},
+		{input: `false`, want: 0},
+		{input: `1`, want: 1},
+		{input: `4`, want: 4},
+		{input: `0`, wantErr: "parallel limit must be positive, got 0"},
+		{input: `-2`, wantErr: "parallel limit must be positive, got -2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var got Parallel
+			err := yaml.UnmarshalStrict([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				g.Should(be.ErrorEqual(err, tt.wantErr))
+				return
+			}
+			g.NoError(err)
+
+			g.Should(be.Equal(got, tt.want))
+		})
+	}
+}
+
+func TestParallel_MarshalYAML(t *testing.T) {
+	for _, p := range []Parallel{parallelUnlimited, 1, 4} {
+		g := ghost.New(t)
+
+		text, err := yaml.Marshal(p)
+		g.NoError(err)
+
+		var got Parallel
+		err = yaml.UnmarshalStrict(text, &got)
+		g.NoError(err)
+
+		g.Should(be.Equal(got, p))
+	}
+}
+
+func TestRun_UnmarshalYAML_parallel(t *testing.T) {
+	g := ghost.New(t)
+
+	var r Run
+	err := yaml.UnmarshalStrict([]byte(`{parallel: true, command: [one, two]}`), &r)
+	g.NoError(err)
+	g.Should(be.Equal(r.Parallel, parallelUnlimited))
+
+	err = yaml.UnmarshalStrict([]byte(`{parallel: 2, set-environment: {FOO: bar}}`), &r)
+	g.Should(be.ErrorEqual(err, "parallel cannot be used with set-environment"))
+}
+
+func TestParallel_runParallel_limit(t *testing.T) {
+	g := ghost.New(t)
+
+	var running, maxRunning atomic.Int32
+	f := func(Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	err := Parallel(2).runParallel(Context{}, []func(Context) error{f, f, f, f, f})
+	g.NoError(err)
+
+	g.Should(be.Equal(maxRunning.Load(), 2))
+}
+
+func TestParallel_runParallel_cancel(t *testing.T) {
+	g := ghost.New(t)
+
+	errFailed := errors.New("failed")
+	started := make(chan struct{})
+
+	var cancelled bool
+	err := parallelUnlimited.runParallel(Context{}, []func(Context) error{
+		func(ctx Context) error {
+			close(started)
+			<-ctx.Context().Done()
+			cancelled = true
+			return ctx.Context().Err()
+		},
+		func(Context) error {
+			<-started
+			return errFailed
+		},
+	})
+
+	g.Should(be.ErrorIs(err, errFailed))
+	g.Check(cancelled)
+}
+
+func TestTask_run_parallel_commands(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	// Each command waits on the other, so they must run concurrently.
+	r := &Run{
+		Parallel: parallelUnlimited,
+		Command: marshal.Slice[*Command]{
+			{Exec: "touch a; while [ ! -f b ]; do sleep 0.01; done", Print: "a"},
+			{Exec: "touch b; while [ ! -f a ]; do sleep 0.01; done", Print: "b"},
+		},
+	}
+
+	var task Task
+	err := task.run(Context{Logger: ui.Noop()}, r, stateRunning)
+	g.NoError(err)
+}
+
+func TestTask_run_parallel_failure(t *testing.T) {
+	g := ghost.New(t)
+
+	r := &Run{
+		Parallel: parallelUnlimited,
+		Command: marshal.Slice[*Command]{
+			{Exec: "sleep 10"},
+			{Exec: "exit 3"},
+		},
+	}
+
+	start := time.Now()
+
+	var task Task
+	err := task.run(Context{Logger: ui.Noop()}, r, stateRunning)
+	g.Should(be.ErrorEqual(err, "exit status 3"))
+
+	g.Check(time.Since(start) < 5*time.Second)
+}
+
+func TestTask_run_parallel_sub_tasks(t *testing.T) {
+	g := ghost.New(t)
+
+	newTask := func(name, exec string) *Task {
+		return &Task{
+			Name: name,
+			RunList: marshal.Slice[*Run]{
+				{Command: marshal.Slice[*Command]{{Exec: exec, Print: exec}}},
+			},
+		}
+	}
+
+	r := &Run{
+		Parallel: parallelUnlimited,
+		Tasks: []*Task{
+			newTask("one", "echo one"),
+			newTask("two", "echo two"),
+		},
+	}
+
+	var stdout bytes.Buffer
+	logger := ui.New(ui.Config{Stdout: &stdout, Stderr: &stdout})
+
+	var task Task
+	err := task.run(Context{Logger: logger}, r, stateRunning)
+	g.NoError(err)
+
+	g.Should(be.StringContaining(stdout.String(), "one | one\n"))
+	g.Should(be.StringContaining(stdout.String(), "two | two\n"))
+}
+
+func TestCommandLabel(t *testing.T) {
+	tests := []struct {
+		print string
+		want  string
+	}{
+		{"echo hello", "echo hello"},
+		{"  echo hello  \n", "echo hello"},
+		{"set -e\necho hello", "set -e …"},
+		{"echo this is a much longer command", "echo this is a much lon…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.print, func(t *testing.T) {
+			g := ghost.New(t)
+
+			g.Should(be.Equal(commandLabel(&Command{Print: tt.print}), tt.want))
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package runner
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
 )
 
 // Parse loads the contents of a config file into a struct.
@@ -27,6 +30,11 @@ type ParseConfig struct {
 	Flags       map[string]string
 	Interpreter []string
 	TaskName    string
+	DryRun      bool
+
+	// Prompter asks for required args and options that were not passed. If
+	// nil, missing values are an error.
+	Prompter *ui.Prompter
 }
 
 // ParseComplete parses the file completely with env file parsing and
@@ -47,20 +55,32 @@ func ParseComplete(meta *ParseConfig) (*Config, error) {
 		return cfg, nil
 	}
 
-	passed, err := combineArgsAndFlags(t, meta.Args, meta.Flags)
+	args, err := promptArgs(meta.Prompter, t, meta.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	passed, err := combineArgsAndFlags(t, args, meta.Flags)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := promptOptions(meta.Prompter, t, cfg, passed); err != nil {
+		return nil, err
+	}
+
 	ctx := Context{
 		CfgPath:     meta.CfgPath,
 		Interpreter: meta.Interpreter,
+		DryRun:      meta.DryRun,
 	}
 
-	if err := passTaskValues(ctx, t, cfg, passed); err != nil {
+	if err := passTaskValues(ctx, t, cfg, make(subTaskCache), []string{t.Name}, passed); err != nil {
 		return nil, err
 	}
 
+	t.cacheConfig = &cfg.Cache
+
 	return cfg, nil
 }
 
@@ -89,39 +109,30 @@ func passTaskValues(
 	ctx Context,
 	t *Task,
 	cfg *Config,
+	built subTaskCache,
+	path []string,
 	passed map[string]string,
 ) error {
-	vars, err := interpolateGlobalOptions(ctx, t, cfg, passed)
+	global, err := globalVariables(t, cfg, passed)
 	if err != nil {
 		return err
 	}
 
-	if err := interpolateTask(ctx, t, passed, vars); err != nil {
+	if err := interpolateTask(ctx, t, passed, global); err != nil {
 		return err
 	}
 
-	return addSubTasks(ctx, t, cfg)
+	return addSubTasks(ctx, t, cfg, built, path)
 }
 
-func interpolateGlobalOptions(
-	ctx Context,
-	t *Task,
-	cfg *Config,
-	passed map[string]string,
-) (map[string]string, error) {
+// globalVariables returns the shared options used by a task.
+func globalVariables(t *Task, cfg *Config, passed map[string]string) (*variables, error) {
 	globalOptions, err := getRequiredGlobalOptions(t, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	vars := make(map[string]string, len(globalOptions))
-	for _, o := range globalOptions {
-		if err := interpolateOption(ctx, o, passed, vars); err != nil {
-			return nil, err
-		}
-	}
-
-	return vars, nil
+	return newVariables(&cfg.optionsMu, passed, globalOptions, nil)
 }
 
 func getRequiredGlobalOptions(t *Task, cfg *Config) (Options, error) {
@@ -166,81 +177,98 @@ func interpolateArg(a *Arg, passed, vars map[string]string) error {
 	return nil
 }
 
-func interpolateOption(ctx Context, o *Option, passed, vars map[string]string) error {
-	if err := marshal.Interpolate(o, vars); err != nil {
-		return err
-	}
-
-	if valuePassed, ok := passed[o.Name]; ok {
-		o.Passed = valuePassed
-	}
-
-	value, err := o.Evaluate(ctx, vars)
+func interpolateTask(ctx Context, t *Task, passed map[string]string, global *variables) error {
+	vars, err := newVariables(global.mu, passed, t.Options, global)
 	if err != nil {
 		return err
 	}
 
-	if o.isBoolean() && o.Rewrite != "" {
-		switch value {
-		case "true":
-			value = o.Rewrite
-		case "false":
-			value = ""
-		}
-	}
-
-	vars[o.Name] = value
-
-	return nil
-}
-
-func interpolateTask(ctx Context, t *Task, passed, vars map[string]string) error {
-	taskVars := make(map[string]string, len(vars)+len(t.Args)+len(t.Options))
-	for k, v := range vars {
-		taskVars[k] = v
-	}
-
 	for _, a := range t.Args {
-		if err := interpolateArg(a, passed, taskVars); err != nil {
+		if err := vars.resolve(ctx, a); err != nil {
 			return err
 		}
-	}
 
-	for _, o := range t.Options {
-		if err := interpolateOption(ctx, o, passed, taskVars); err != nil {
+		if err := interpolateArg(a, passed, vars.values); err != nil {
 			return err
 		}
 	}
 
-	if err := marshal.Interpolate(&t.RunList, taskVars); err != nil {
-		return err
-	}
+	// Commands are interpolated as they run, so that they can use the output
+	// captured by earlier commands, and so that options are only evaluated if
+	// they are used. Sub-tasks are needed to finish parsing.
+	for _, r := range t.AllRunItems() {
+		if len(r.SubTaskList) == 0 {
+			continue
+		}
 
-	if err := marshal.Interpolate(&t.Finally, taskVars); err != nil {
-		return err
+		if err := vars.resolve(ctx, r.SubTaskList); err != nil {
+			return err
+		}
+
+		if err := marshal.Interpolate(&r.SubTaskList, vars.values); err != nil {
+			return err
+		}
 	}
 
-	t.Vars = taskVars
+	t.Vars = vars.values
+	t.vars = vars
 
 	return nil
 }
 
-func addSubTasks(ctx Context, t *Task, cfg *Config) error {
+// addSubTasks builds the sub-tasks of a task. The path is the list of task
+// names leading to the task, which is used to detect cycles.
+func addSubTasks(ctx Context, t *Task, cfg *Config, built subTaskCache, path []string) error {
 	for _, run := range t.AllRunItems() {
 		for _, desc := range run.SubTaskList {
-			sub, err := newTaskFromSub(ctx, desc, cfg)
+			sub, err := newTaskFromSub(ctx, desc, cfg, built, path)
 			if err != nil {
 				return err
 			}
 
-			run.Tasks = append(run.Tasks, *sub)
+			run.Tasks = append(run.Tasks, sub)
 		}
 	}
 
 	return nil
 }
 
-func newTaskFromSub(ctx Context, desc *SubTask, cfg *Config) (*Task, error) {
+// subTaskCache stores the sub-tasks that have been built during parsing, so
+// that each combination of task, args, and options is only built once.
+type subTaskCache map[string]*Task
+
+// key returns a unique key for a sub-task description.
+func (subTaskCache) key(desc *SubTask) (string, error) {
+	// Map keys are sorted when marshaled, so this is deterministic. Retry
+	// policies do not affect the task that is built, so they are excluded.
+	b, err := json.Marshal(desc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newTaskFromSub(
+	ctx Context,
+	desc *SubTask,
+	cfg *Config,
+	built subTaskCache,
+	path []string,
+) (*Task, error) {
+	if i := slices.Index(path, desc.Name); i >= 0 {
+		return nil, newCycleError("sub-task", append(slices.Clone(path[i:]), desc.Name))
+	}
+	path = append(slices.Clip(path), desc.Name)
+
+	key, err := built.key(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub, ok := built[key]; ok {
+		return sub, nil
+	}
+
 	st, ok := cfg.Tasks[desc.Name]
 	if !ok {
 		return nil, fmt.Errorf("sub-task %q is not defined", desc.Name)
@@ -269,10 +297,12 @@ func newTaskFromSub(ctx Context, desc *SubTask, cfg *Config) (*Task, error) {
 		values[optName] = optValue
 	}
 
-	if err := passTaskValues(ctx, subTask, cfg, values); err != nil {
+	if err := passTaskValues(ctx, subTask, cfg, built, path, values); err != nil {
 		return nil, err
 	}
 
+	built[key] = subTask
+
 	return subTask, nil
 }
 
@@ -294,9 +324,31 @@ func copyTask(t *Task) *Task {
 	}
 	newTask.Options = optionsCopy
 
+	newTask.RunList = copyRuns(newTask.RunList)
+	newTask.Finally = copyRuns(newTask.Finally)
+
 	return &newTask
 }
 
+// copyRuns returns a copy of a list of run items. Sub-task descriptions are
+// interpolated and their tasks built separately for each copy, so the copies
+// start without any built sub-tasks.
+func copyRuns(runs marshal.Slice[*Run]) marshal.Slice[*Run] {
+	if runs == nil {
+		return nil
+	}
+
+	runsCopy := make(marshal.Slice[*Run], 0, len(runs))
+	for _, ptr := range runs {
+		run := *ptr
+		run.SubTaskList = slices.Clone(run.SubTaskList)
+		run.Tasks = nil
+		runsCopy = append(runsCopy, &run)
+	}
+
+	return runsCopy
+}
+
 func getArgValues(subTask *Task, argsPassed []string) (map[string]string, error) {
 	if len(argsPassed) != len(subTask.Args) {
 		return nil, fmt.Errorf(
@@ -965,7 +965,7 @@ given input:
 			})
 			g.NoError(err)
 
-			got := flattenRuns(cfg.Tasks[tt.taskName].AllRunItems())
+			got := flattenRuns(t, cfg.Tasks[tt.taskName])
 			g.Should(be.DeepEqual(got, tt.want))
 		})
 
@@ -997,23 +997,34 @@ given input:
 			})
 			g.NoError(err)
 
-			got := flattenRuns(cfg.Tasks[tt.taskName].AllRunItems())
+			got := flattenRuns(t, cfg.Tasks[tt.taskName])
 			g.Should(be.DeepEqual(got, tt.want))
 		})
 	}
 }
 
-func flattenRuns(runList marshal.Slice[*Run]) marshal.Slice[*Run] {
+// flattenRuns returns the run items of a task and its sub-tasks, interpolated
+// as they would be when run.
+func flattenRuns(t *testing.T, task *Task) marshal.Slice[*Run] {
 	var flattened marshal.Slice[*Run]
 
-	for _, run := range runList {
+	for _, run := range task.AllRunItems() {
 		if len(run.Tasks) == 0 {
-			flattened = append(flattened, run)
+			err := task.vars.resolve(Context{}, &run.When, run.Command, run.SetEnvironment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			interpolated, err := run.interpolate(task.Vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flattened = append(flattened, interpolated)
 			continue
 		}
 
 		for i := range run.Tasks {
-			flattened = append(flattened, flattenRuns(run.Tasks[i].AllRunItems())...)
+			flattened = append(flattened, flattenRuns(t, run.Tasks[i])...)
 		}
 	}
 
@@ -1160,6 +1171,55 @@ tasks:
 		taskName: "mytask",
 		wantErr:  `sub-task "fake" is not defined`,
 	},
+	{
+		name: "sub-task includes itself",
+		input: `
+tasks:
+  mytask:
+    run:
+      task: mytask
+`,
+		taskName: "mytask",
+		wantErr:  `sub-task cycle detected: mytask -> mytask`,
+	},
+	{
+		name: "sub-task cycle",
+		input: `
+tasks:
+  a:
+    run:
+      task: b
+  b:
+    run:
+      - echo hello
+      - task: {name: c, args: [foo]}
+  c:
+    args:
+      foo: {}
+    run:
+      task: a
+  mytask:
+    run:
+      task: a
+`,
+		taskName: "mytask",
+		wantErr:  `sub-task cycle detected: a -> b -> c -> a`,
+	},
+	{
+		name: "option cycle",
+		input: `
+options:
+  foo:
+    default: ${bar}
+  bar:
+    default: ${foo}
+tasks:
+  mytask:
+    run: echo ${foo}
+`,
+		taskName: "mytask",
+		wantErr:  `option cycle detected: foo -> bar -> foo`,
+	},
 	{
 		name: "argument and option share name",
 		input: `
@@ -1240,6 +1300,81 @@ tasks:
 		taskName: "mytask",
 		wantErr:  "task target cannot be defined without source",
 	},
+
+	{
+		name: "cache-env without source",
+		input: `
+tasks:
+  mytask:
+    cache-env: GOOS
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  "task cache-env cannot be defined without source and target",
+	},
+
+	{
+		name: "gitignore without source",
+		input: `
+tasks:
+  mytask:
+    gitignore: true
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  "task gitignore cannot be defined without source and target",
+	},
+
+	{
+		name: "only excluded sources",
+		input: `
+tasks:
+  mytask:
+    source: "!vendor/**"
+    target: foo.txt
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  "task source must contain a pattern that is not an exclusion",
+	},
+
+	{
+		name: "only excluded watch patterns",
+		input: `
+tasks:
+  mytask:
+    watch: "!vendor/**"
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  "task watch must contain a pattern that is not an exclusion",
+	},
+
+	{
+		name: "negative cache max-age",
+		input: `
+cache:
+  max-age: -1h
+tasks:
+  mytask:
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  "cache max-age must not be negative, got -1h0m0s",
+	},
+
+	{
+		name: "invalid cache max-size",
+		input: `
+cache:
+  max-size: 10 apples
+tasks:
+  mytask:
+    run: echo ${bar}
+`,
+		taskName: "mytask",
+		wantErr:  `invalid size "10 apples", expected a number with a unit such as 500MB`,
+	},
 }
 
 func TestParseComplete_invalid(t *testing.T) {
@@ -1327,3 +1462,76 @@ tasks:
 	g.Check(cfg.Tasks["quietCmd"].RunList[0].Command[0].Quiet)
 	g.Check(cfg.Tasks["quietTask"].Quiet)
 }
+
+func TestParseComplete_sub_tasks_deduplicated(t *testing.T) {
+	g := ghost.New(t)
+
+	cfgText := []byte(`
+tasks:
+  setup:
+    args:
+      target: {}
+    run: echo setup ${target}
+  lint:
+    run:
+      - task: {name: setup, args: [a]}
+  test:
+    run:
+      - task: {name: setup, args: [a]}
+      - task: {name: setup, args: [b]}
+  all:
+    run:
+      - task: [lint, test]
+`)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  cfgText,
+		TaskName: "all",
+	})
+	g.NoError(err)
+
+	subTasks := cfg.Tasks["all"].RunList[0].Tasks
+	g.Must(be.SliceLen(subTasks, 2))
+
+	lint, test := subTasks[0], subTasks[1]
+	setupFromLint := lint.RunList[0].Tasks[0]
+	setupFromTestA := test.RunList[0].Tasks[0]
+	setupFromTestB := test.RunList[1].Tasks[0]
+
+	g.Should(be.True(setupFromLint == setupFromTestA))
+	g.Should(be.False(setupFromLint == setupFromTestB))
+}
+
+func TestParseComplete_sub_tasks_called_with_different_args(t *testing.T) {
+	g := ghost.New(t)
+
+	cfgText := []byte(`
+tasks:
+  say:
+    args:
+      text: {}
+    run: echo ${text}
+  greet:
+    args:
+      name: {}
+    run:
+      task: {name: say, args: ["${name}"]}
+  main:
+    run:
+      - task: {name: greet, args: [a]}
+      - task: {name: greet, args: [b]}
+`)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  cfgText,
+		TaskName: "main",
+	})
+	g.NoError(err)
+
+	runs := flattenRuns(t, cfg.Tasks["main"])
+	g.Must(be.SliceLen(runs, 2))
+	g.Should(be.Equal(runs[0].Command[0].Exec, "echo a"))
+	g.Should(be.Equal(runs[1].Command[0].Exec, "echo b"))
+
+	g.Should(be.SliceLen(cfg.Tasks["greet"].RunList[0].Tasks, 0))
+}
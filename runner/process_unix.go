@@ -0,0 +1,53 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+// setProcessGroup starts a command in its own process group, so that signals
+// can be forwarded to every process it starts.
+//
+// Commands are left in the foreground process group while tusk is attached to
+// a terminal, so that they can still read from it. They receive signals sent
+// by the terminal directly.
+func setProcessGroup(cmd *exec.Cmd) {
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		return
+	}
+
+	setOwnProcessGroup(cmd)
+}
+
+// setOwnProcessGroup starts a command in its own process group, even while tusk
+// is attached to a terminal. It is used for commands that do not read from the
+// terminal, so that every process they start can be stopped with them.
+func setOwnProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcess sends a signal to a command, along with every process in its
+// process group.
+func signalProcess(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+		return syscall.Kill(-cmd.Process.Pid, s)
+	}
+
+	// Commands in the foreground process group have already received any
+	// interrupt from the terminal.
+	if s == syscall.SIGINT {
+		return nil
+	}
+
+	return cmd.Process.Signal(s)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op, as processes on Windows cannot be signalled.
+func setProcessGroup(*exec.Cmd) {}
+
+// setOwnProcessGroup is a no-op, as processes on Windows cannot be signalled.
+func setOwnProcessGroup(*exec.Cmd) {}
+
+// signalProcess kills a command, as processes on Windows cannot be signalled.
+func signalProcess(cmd *exec.Cmd, _ os.Signal) error {
+	return cmd.Process.Kill()
+}
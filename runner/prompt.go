@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"os"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// promptArgs asks for the args of a task that were not passed, and returns
+// every arg value in order.
+func promptArgs(prompter *ui.Prompter, t *Task, args []string) ([]string, error) {
+	if prompter == nil || len(args) >= len(t.Args) {
+		return args, nil
+	}
+
+	for _, a := range t.Args[len(args):] {
+		value, err := prompter.Prompt(ui.Prompt{
+			Kind:     "argument",
+			Name:     a.Name,
+			Usage:    a.Usage,
+			Values:   a.ValuesAllowed,
+			Validate: a.validatePassed,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	return args, nil
+}
+
+// promptOptions asks for the required options used by a task that were not
+// passed, and adds their values to the values passed.
+func promptOptions(prompter *ui.Prompter, t *Task, cfg *Config, passed map[string]string) error {
+	if prompter == nil {
+		return nil
+	}
+
+	global, err := getRequiredGlobalOptions(t, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range append(global, t.Options...) {
+		if !o.needsPrompt(passed) {
+			continue
+		}
+
+		value, err := prompter.Prompt(ui.Prompt{
+			Kind:     "option",
+			Name:     o.Name,
+			Usage:    o.Usage,
+			Values:   o.ValuesAllowed,
+			Secret:   o.Secret,
+			Validate: o.validatePassed,
+		})
+		if err != nil {
+			return err
+		}
+
+		passed[o.Name] = value
+	}
+
+	return nil
+}
+
+// needsPrompt reports whether an option is required, but has no value passed
+// or set in its environment variable. An empty value that was passed
+// explicitly is not asked for again.
+func (o *Option) needsPrompt(passed map[string]string) bool {
+	if _, ok := passed[o.Name]; ok || !o.Required || o.Private {
+		return false
+	}
+
+	return o.Environment == "" || os.Getenv(o.Environment) == ""
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+var promptCfgText = []byte(`
+options:
+  region:
+    required: true
+    values: [us, eu]
+  unused:
+    required: true
+tasks:
+  deploy:
+    args:
+      target:
+        usage: What to deploy
+    options:
+      token:
+        required: true
+        secret: true
+      env:
+        required: true
+        environment: DEPLOY_ENV
+    run: echo ${target} ${region} ${token} ${env}
+`)
+
+func TestParseComplete_prompt(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("DEPLOY_ENV", "staging")
+
+	var out bytes.Buffer
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  promptCfgText,
+		TaskName: "deploy",
+		Prompter: ui.NewPrompter(strings.NewReader("api\nfr\n2\nsecret\n"), &out),
+	})
+	g.NoError(err)
+
+	runs := flattenRuns(t, cfg.Tasks["deploy"])
+	g.Should(be.Equal(runs[0].Command[0].Exec, "echo api eu secret staging"))
+	g.Should(be.StringContaining(out.String(), `argument "target" (What to deploy)`))
+	g.Should(be.StringContaining(
+		out.String(), `value "fr" for option "region" must be one of [us, eu]`,
+	))
+	g.ShouldNot(be.StringContaining(out.String(), `"unused"`))
+	g.ShouldNot(be.StringContaining(out.String(), `"env"`))
+}
+
+func TestParseComplete_prompt_passed(t *testing.T) {
+	g := ghost.New(t)
+
+	var out bytes.Buffer
+	_, err := ParseComplete(&ParseConfig{
+		CfgText:  promptCfgText,
+		TaskName: "deploy",
+		Args:     []string{"api"},
+		Flags:    map[string]string{"region": "us", "token": "secret", "env": "prod"},
+		Prompter: ui.NewPrompter(strings.NewReader(""), &out),
+	})
+	g.NoError(err)
+	g.Should(be.Zero(out.String()))
+}
+
+func TestParseComplete_prompt_passed_empty(t *testing.T) {
+	g := ghost.New(t)
+
+	var out bytes.Buffer
+	_, err := ParseComplete(&ParseConfig{
+		CfgText:  promptCfgText,
+		TaskName: "deploy",
+		Args:     []string{"api"},
+		Flags:    map[string]string{"region": "us", "token": "", "env": "prod"},
+		Prompter: ui.NewPrompter(strings.NewReader(""), &out),
+	})
+	g.Should(be.ErrorEqual(err, "no value passed for required option: token"))
+	g.Should(be.Zero(out.String()))
+}
+
+func TestParseComplete_prompt_eof(t *testing.T) {
+	g := ghost.New(t)
+
+	var out bytes.Buffer
+	_, err := ParseComplete(&ParseConfig{
+		CfgText:  promptCfgText,
+		TaskName: "deploy",
+		Args:     []string{"api"},
+		Prompter: ui.NewPrompter(strings.NewReader(""), &out),
+	})
+	g.Should(be.ErrorEqual(err, `no value entered for option "region": EOF`))
+}
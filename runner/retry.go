@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// maxBackoffDelay is the longest that exponential backoff grows the delay
+// between retries, unless the initial delay is already longer.
+const maxBackoffDelay = time.Hour
+
+// Backoff is the strategy used to wait between retries.
+type Backoff string
+
+// The available backoff strategies.
+const (
+	BackoffFixed       Backoff = "fixed"
+	BackoffExponential Backoff = "exponential"
+)
+
+// Retry is the policy for retrying a failed command or sub-task.
+type Retry struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int `yaml:"attempts"`
+
+	// Delay is the time to wait before the first retry.
+	Delay time.Duration `yaml:"delay,omitempty"`
+
+	// Backoff determines how the delay grows between retries.
+	Backoff Backoff `yaml:"backoff,omitempty"`
+
+	// ExitCodes limits retries to failures with one of the exit codes.
+	ExitCodes []int `yaml:"exit-codes,omitempty"`
+}
+
+// UnmarshalYAML allows a number of attempts to represent a retry policy.
+func (r *Retry) UnmarshalYAML(unmarshal func(any) error) error {
+	var attempts int
+	attemptsCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&attempts) },
+		Validate:  func() error { return (&Retry{Attempts: attempts}).isValid() },
+		Assign:    func() { *r = Retry{Attempts: attempts} },
+	}
+
+	type retryType Retry // Use new type to avoid recursion
+	var retryItem retryType
+	retryCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&retryItem) },
+		Validate:  func() error { return (*Retry)(&retryItem).isValid() },
+		Assign:    func() { *r = Retry(retryItem) },
+	}
+
+	return marshal.UnmarshalOneOf(attemptsCandidate, retryCandidate)
+}
+
+// isValid checks whether a retry policy is valid.
+func (r *Retry) isValid() error {
+	if r.Attempts < 1 {
+		return fmt.Errorf("retry attempts must be positive, got %d", r.Attempts)
+	}
+
+	switch r.Backoff {
+	case "", BackoffFixed:
+	case BackoffExponential:
+		if r.Delay <= 0 {
+			return errors.New("exponential backoff requires a retry delay")
+		}
+	default:
+		return fmt.Errorf(
+			"invalid retry backoff %q (one of: %s, %s)", r.Backoff, BackoffFixed, BackoffExponential,
+		)
+	}
+
+	return nil
+}
+
+// do calls f until it succeeds or the policy does not allow another attempt.
+// A nil policy calls f once.
+//
+// The attempt passed to f starts at 1.
+func (r *Retry) do(ctx Context, name string, f func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := f(attempt)
+		if err == nil || !r.shouldRetry(ctx, attempt, err) {
+			return err
+		}
+
+		delay := r.delay(attempt)
+		ctx.Logger.PrintRetry(name, attempt+1, r.Attempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Context().Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether another attempt should be made after an error.
+func (r *Retry) shouldRetry(ctx Context, attempt int, err error) bool {
+	if r == nil || attempt >= r.Attempts || ctx.Context().Err() != nil {
+		return false
+	}
+
+	return len(r.ExitCodes) == 0 || slices.Contains(r.ExitCodes, exitCode(err))
+}
+
+// delay returns the time to wait after a failed attempt. Exponential backoff
+// doubles the delay after each attempt, up to a limit.
+func (r *Retry) delay(attempt int) time.Duration {
+	if r.Backoff != BackoffExponential {
+		return r.Delay
+	}
+
+	limit := max(r.Delay, maxBackoffDelay)
+	delay := r.Delay
+	for range attempt - 1 {
+		if delay >= limit/2 {
+			return limit
+		}
+		delay *= 2
+	}
+
+	return delay
+}
@@ -0,0 +1,248 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestRetry_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Retry
+		wantErr string
+	}{
+		{
+			name:  "attempts",
+			input: `3`,
+			want:  Retry{Attempts: 3},
+		},
+		{
+			name:  "full",
+			input: `{attempts: 4, delay: 1s, backoff: exponential, exit-codes: [1, 2]}`,
+			want: Retry{
+				Attempts:  4,
+				Delay:     time.Second,
+				Backoff:   BackoffExponential,
+				ExitCodes: []int{1, 2},
+			},
+		},
+		{
+			name:    "zero attempts",
+			input:   `0`,
+			wantErr: "retry attempts must be positive, got 0",
+		},
+		{
+			name:    "missing attempts",
+			input:   `{delay: 1s}`,
+			wantErr: "retry attempts must be positive, got 0",
+		},
+		{
+			name:    "invalid backoff",
+			input:   `{attempts: 2, backoff: linear}`,
+			wantErr: `invalid retry backoff "linear" (one of: fixed, exponential)`,
+		},
+		{
+			name:    "exponential without delay",
+			input:   `{attempts: 2, backoff: exponential}`,
+			wantErr: "exponential backoff requires a retry delay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var got Retry
+			err := yaml.UnmarshalStrict([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				g.Should(be.ErrorEqual(err, tt.wantErr))
+				return
+			}
+			g.NoError(err)
+
+			g.Should(be.DeepEqual(got, tt.want))
+		})
+	}
+}
+
+func TestRetry_delay(t *testing.T) {
+	g := ghost.New(t)
+
+	fixed := Retry{Attempts: 4, Delay: time.Second}
+	g.Should(be.Equal(fixed.delay(1), time.Second))
+	g.Should(be.Equal(fixed.delay(3), time.Second))
+
+	exponential := Retry{Attempts: 4, Delay: time.Second, Backoff: BackoffExponential}
+	g.Should(be.Equal(exponential.delay(1), time.Second))
+	g.Should(be.Equal(exponential.delay(2), 2*time.Second))
+	g.Should(be.Equal(exponential.delay(3), 4*time.Second))
+	g.Should(be.Equal(exponential.delay(13), maxBackoffDelay))
+	g.Should(be.Equal(exponential.delay(100), maxBackoffDelay))
+	g.Should(be.Equal(exponential.delay(math.MaxInt), maxBackoffDelay))
+
+	long := Retry{Attempts: 100, Delay: 2 * time.Hour, Backoff: BackoffExponential}
+	g.Should(be.Equal(long.delay(1), 2*time.Hour))
+	g.Should(be.Equal(long.delay(100), 2*time.Hour))
+}
+
+func TestRetry_do(t *testing.T) {
+	g := ghost.New(t)
+
+	var stderr bytes.Buffer
+	logger := ui.New(ui.Config{Stdout: io.Discard, Stderr: &stderr})
+
+	retry := &Retry{Attempts: 3}
+
+	var attempts []int
+	err := retry.do(Context{Logger: logger}, "flaky", func(attempt int) error {
+		attempts = append(attempts, attempt)
+		if attempt < 3 {
+			return errors.New("oops")
+		}
+		return nil
+	})
+	g.NoError(err)
+	g.Should(be.DeepEqual(attempts, []int{1, 2, 3}))
+
+	g.Should(be.Equal(stderr.String(), `Retrying: flaky
+ => attempt 2 of 3 in 0s after error: oops
+Retrying: flaky
+ => attempt 3 of 3 in 0s after error: oops
+`))
+}
+
+func TestRetry_do_exhausted(t *testing.T) {
+	g := ghost.New(t)
+
+	retry := &Retry{Attempts: 2}
+
+	var count int
+	err := retry.do(Context{Logger: ui.Noop()}, "flaky", func(int) error {
+		count++
+		return errors.New("oops")
+	})
+	g.Should(be.ErrorEqual(err, "oops"))
+	g.Should(be.Equal(count, 2))
+}
+
+func TestRetry_do_nil(t *testing.T) {
+	g := ghost.New(t)
+
+	var retry *Retry
+
+	var count int
+	err := retry.do(Context{Logger: ui.Noop()}, "flaky", func(int) error {
+		count++
+		return errors.New("oops")
+	})
+	g.Should(be.ErrorEqual(err, "oops"))
+	g.Should(be.Equal(count, 1))
+}
+
+func TestRetry_do_exit_codes(t *testing.T) {
+	g := ghost.New(t)
+
+	retry := &Retry{Attempts: 3, ExitCodes: []int{2}}
+
+	var count int
+	err := retry.do(Context{Logger: ui.Noop()}, "flaky", func(int) error {
+		count++
+		return exec.Command("sh", "-c", "exit 1").Run()
+	})
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+	g.Should(be.Equal(count, 1))
+
+	count = 0
+	err = retry.do(Context{Logger: ui.Noop()}, "flaky", func(int) error {
+		count++
+		return exec.Command("sh", "-c", "exit 2").Run()
+	})
+	g.Should(be.ErrorEqual(err, "exit status 2"))
+	g.Should(be.Equal(count, 3))
+}
+
+func TestTask_Execute_retry(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+tasks:
+  flaky:
+    run: echo >> flaky.txt && test "$(wc -l < flaky.txt)" -ge 2
+  mytask:
+    run:
+      - task:
+          name: flaky
+          retry: 2
+      - task: flaky
+      - command:
+          exec: echo >> command.txt && test "$(wc -l < command.txt)" -ge 3
+          retry: {attempts: 3, delay: 1ms}
+`),
+		TaskName: "mytask",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["mytask"].Execute(Context{Logger: ui.Noop()})
+	g.NoError(err)
+
+	flaky, err := os.ReadFile("flaky.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(flaky), "\n\n"))
+
+	command, err := os.ReadFile("command.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(command), "\n\n\n"))
+}
+
+func TestTask_Execute_retry_nested(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+tasks:
+  flaky:
+    run: echo >> flaky.txt && test "$(wc -l < flaky.txt)" -ge 2
+  wrapper:
+    run:
+      - task: flaky
+      - echo >> wrapper.txt
+  mytask:
+    run:
+      task:
+        name: wrapper
+        retry: 3
+`),
+		TaskName: "mytask",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["mytask"].Execute(Context{Logger: ui.Noop()})
+	g.NoError(err)
+
+	flaky, err := os.ReadFile("flaky.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(flaky), "\n\n"))
+
+	wrapper, err := os.ReadFile("wrapper.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(wrapper), "\n"))
+}
@@ -12,9 +12,21 @@ type Run struct {
 	Command        marshal.Slice[*Command] `yaml:",omitempty"`
 	SubTaskList    marshal.Slice[*SubTask] `yaml:"task,omitempty"`
 	SetEnvironment map[string]*string      `yaml:"set-environment,omitempty"`
+	Parallel       Parallel                `yaml:",omitempty"`
+
+	// ContinueOnError runs the remaining actions and run items after a failure.
+	ContinueOnError bool `yaml:"continue-on-error,omitempty"`
+
+	// Background starts the commands without waiting for them to exit. They
+	// are stopped once the task is done with them.
+	Background bool `yaml:"background,omitempty"`
+
+	// Ready is a probe that must succeed for each background command before
+	// the task continues.
+	Ready *Ready `yaml:"ready,omitempty"`
 
 	// Computed members not specified in yaml file
-	Tasks []Task `yaml:"-"`
+	Tasks []*Task `yaml:"-" json:"-"`
 }
 
 // UnmarshalYAML allows simple commands to represent run structs.
@@ -48,13 +60,44 @@ func (r *Run) UnmarshalYAML(unmarshal func(any) error) error {
 				return errors.New("only one action can be defined in `run`")
 			}
 
-			return nil
+			if runItem.Parallel != 0 && runItem.SetEnvironment != nil {
+				return errors.New("parallel cannot be used with set-environment")
+			}
+
+			r := Run(runItem)
+			return r.validateBackground()
 		},
 	}
 
 	return marshal.UnmarshalOneOf(commandCandidate, runCandidate)
 }
 
+// interpolate returns a copy of the run item with its conditions and actions
+// interpolated. Sub-tasks are interpolated when the task is parsed, so they are
+// left as they are.
+func (r *Run) interpolate(vars map[string]string) (*Run, error) {
+	type actions struct {
+		When           WhenList                `yaml:",omitempty"`
+		Command        marshal.Slice[*Command] `yaml:",omitempty"`
+		SetEnvironment map[string]*string      `yaml:"set-environment,omitempty"`
+		Ready          *Ready                  `yaml:",omitempty"`
+	}
+
+	// A list is always unmarshaled into new items, so the run is not modified.
+	list := []actions{{r.When, r.Command, r.SetEnvironment, r.Ready}}
+	if err := marshal.Interpolate(&list, vars); err != nil {
+		return nil, err
+	}
+
+	interpolated := *r
+	interpolated.When = list[0].When
+	interpolated.Command = list[0].Command
+	interpolated.SetEnvironment = list[0].SetEnvironment
+	interpolated.Ready = list[0].Ready
+
+	return &interpolated, nil
+}
+
 func (r *Run) shouldRun(ctx Context, vars map[string]string) (bool, error) {
 	if err := r.When.Validate(ctx, vars); err != nil {
 		if !IsFailedCondition(err) {
@@ -72,5 +115,23 @@ func (r *Run) shouldRun(ctx Context, vars map[string]string) (bool, error) {
 		return false, nil
 	}
 
+	if len(r.When) != 0 {
+		for _, command := range r.Command {
+			ctx.Logger.PrintConditionMet(command.Print)
+		}
+
+		for _, subTask := range r.SubTaskList {
+			ctx.Logger.PrintConditionMet(subTask.Name)
+		}
+	}
+
 	return true, nil
 }
+
+// subTaskRetry returns the retry policy of the sub-task at index i.
+func (r *Run) subTaskRetry(i int) *Retry {
+	if i >= len(r.SubTaskList) {
+		return nil
+	}
+	return r.SubTaskList[i].Retry
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalError is the cause of a run that was cancelled by a signal.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("received signal: %s", e.Signal)
+}
+
+// ExitCode returns the conventional exit code of a process terminated by the
+// signal.
+func (e *SignalError) ExitCode() int {
+	if s, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// NotifySignals returns a context that is cancelled with a [SignalError] once
+// the process is interrupted or terminated.
+//
+// Only the first signal is trapped, so that a second signal stops the process
+// immediately. The returned function stops trapping signals.
+func NotifySignals(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			cancel(&SignalError{Signal: sig})
+		case <-stopped:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(stopped)
+		cancel(context.Canceled)
+	}
+}
+
+// cancelSignal returns the signal to send to commands when the context is
+// cancelled.
+func cancelSignal(ctx Context) os.Signal {
+	var sigErr *SignalError
+	if errors.As(context.Cause(ctx.Context()), &sigErr) {
+		return sigErr.Signal
+	}
+
+	return syscall.SIGTERM
+}
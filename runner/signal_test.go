@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+)
+
+func TestSignalError_ExitCode(t *testing.T) {
+	g := ghost.New(t)
+
+	g.Should(be.Equal((&SignalError{Signal: os.Interrupt}).ExitCode(), 130))
+	g.Should(be.Equal((&SignalError{Signal: syscall.SIGTERM}).ExitCode(), 143))
+}
+
+func waitForFile(t testing.TB, name string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(name); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %s", name)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := os.WriteFile("cache", []byte("old"), 0o400)
+	g.NoError(err)
+
+	err = writeFileAtomic("cache", []byte("new"), 0o600)
+	g.NoError(err)
+
+	got, err := os.ReadFile("cache")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "new"))
+
+	entries, err := os.ReadDir(".")
+	g.NoError(err)
+	g.Should(be.SliceLen(entries, 1))
+}
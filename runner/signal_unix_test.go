@@ -0,0 +1,116 @@
+//go:build unix
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestNotifySignals(t *testing.T) {
+	g := ghost.New(t)
+
+	ctx, stop := NotifySignals(context.Background())
+	defer stop()
+
+	err := syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	g.NoError(err)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled")
+	}
+
+	g.Should(be.ErrorEqual(context.Cause(ctx), "received signal: terminated"))
+}
+
+func TestTask_Execute_signal(t *testing.T) {
+	g := ghost.New(t)
+
+	wd := xtesting.UseTempDir(t)
+	t.Setenv("TUSK_TEST_SIGNAL_HELPER", "1")
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(fmt.Sprintf(`
+tasks:
+  inner:
+    run: exec '%s' -test.run=TestTask_Execute_signal_helper
+    finally: echo inner >> finally.txt
+  outer:
+    run:
+      - task: inner
+      - touch skipped.txt
+    finally: echo outer >> finally.txt
+`, os.Args[0])),
+		TaskName: "outer",
+	})
+	g.NoError(err)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- cfg.Tasks["outer"].Execute(Context{
+			CfgPath: filepath.Join(wd, "tusk.yml"),
+			Logger:  ui.Noop(),
+		}.WithContext(ctx))
+	}()
+
+	waitForFile(t, "ready.txt")
+	cancel(&SignalError{Signal: syscall.SIGTERM})
+
+	select {
+	case err = <-errc:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task did not stop")
+	}
+
+	g.Should(be.ErrorEqual(err, "received signal: terminated: exit status 143"))
+
+	received, err := os.ReadFile("received.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(received), "terminated"))
+
+	finally, err := os.ReadFile("finally.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(finally), "inner\nouter\n"))
+
+	_, err = os.Stat("skipped.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+// TestTask_Execute_signal_helper is a fake process that records the signal it
+// receives. It only runs when TUSK_TEST_SIGNAL_HELPER is set to "1".
+func TestTask_Execute_signal_helper(*testing.T) {
+	if os.Getenv("TUSK_TEST_SIGNAL_HELPER") != "1" {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+
+	if err := os.WriteFile("ready.txt", nil, 0o600); err != nil {
+		os.Exit(1)
+	}
+
+	sig := <-signals
+	if err := os.WriteFile("received.txt", []byte(sig.String()), 0o600); err != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(143)
+}
@@ -1,24 +1,30 @@
 package runner
 
 import (
-	"cmp"
+	"bytes"
 	"context"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"hash"
 	"hash/fnv"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"slices"
+	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/rliebz/tusk/internal/xdg"
+	"github.com/rliebz/tusk/marshal"
 )
 
 // CleanCache deletes all cached files.
@@ -74,44 +80,180 @@ func CleanTaskCache(cfgPath string, task string) error {
 	return nil
 }
 
-func (t *Task) isUpToDate(ctx Context, cachePath string) (bool, error) {
-	if !t.isCacheable() {
-		return false, nil
-	}
+// cacheVersion is the version of the task cache format. Changing it
+// invalidates every existing cache entry.
+const cacheVersion = 5
+
+// cacheEntry is the content of a task cache file.
+//
+// Along with the checksum of the targets, the entry records the checksum of
+// each file and the other inputs of the task, so that changes since the
+// entry was written can be explained.
+type cacheEntry struct {
+	Version int        `json:"version"`
+	Config  string     `json:"config"`
+	Task    string     `json:"task"`
+	Target  string     `json:"target"`
+	Targets manifest   `json:"targets"`
+	Inputs  taskInputs `json:"inputs"`
+}
 
-	cachedChecksumBytes, err := os.ReadFile(cachePath)
+// readCacheEntry reads a task cache file. Files that are missing, cannot be
+// parsed, or were written by another version are treated as empty.
+func readCacheEntry(cachePath string) (cacheEntry, error) {
+	data, err := os.ReadFile(cachePath)
 	if errors.Is(err, fs.ErrNotExist) {
+		return cacheEntry{}, nil
+	}
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Version != cacheVersion {
+		return cacheEntry{}, nil
+	}
+
+	return entry, nil
+}
+
+func (t *Task) isUpToDate(ctx Context, cachePath string, stats *statCache) (bool, error) {
+	if !t.isCacheable() {
 		return false, nil
 	}
+
+	entry, err := readCacheEntry(cachePath)
 	if err != nil {
 		return false, err
 	}
 
-	if len(cachedChecksumBytes) == 0 {
+	if entry.Target == "" {
 		return false, nil
 	}
 
-	outputChecksum, err := t.outputChecksum(ctx)
+	outputChecksum, err := t.outputChecksum(ctx, stats)
 	if err != nil {
 		return false, err
 	}
 
-	return outputChecksum == string(cachedChecksumBytes), nil
+	return outputChecksum == entry.Target, nil
 }
 
 // taskInputCachePath returns a unique file path based on the inputs of a task.
-func (t *Task) taskInputCachePath(c Context) (string, error) {
+func (t *Task) taskInputCachePath(c Context, inputs taskInputs) (string, error) {
 	taskCacheDir, err := taskCacheDir(c.CfgPath, t.Name)
 	if err != nil {
 		return "", err
 	}
 
-	filename, err := dirChecksum("source", os.DirFS(c.Dir()), t.Source)
+	key, err := inputs.key()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(taskCacheDir, key), nil
+}
+
+// taskInputs are every input of a task that can change what it produces.
+type taskInputs struct {
+	// Sources holds the checksum of each source file.
+	Sources manifest `json:"sources"`
+
+	// Definition is a checksum of the name, targets, and run items of the task.
+	Definition string `json:"definition"`
+
+	// Vars and Env hold a checksum of each value by name. The values
+	// themselves are left out of the cache, since they may be sensitive.
+	Vars        map[string]string `json:"vars"`
+	Interpreter []string          `json:"interpreter"`
+	Env         map[string]string `json:"env"`
+}
+
+// taskDefinition is the part of a task definition that can change what it
+// produces.
+type taskDefinition struct {
+	Name    string              `json:"name"`
+	Target  []string            `json:"target"`
+	Run     marshal.Slice[*Run] `json:"run"`
+	Finally marshal.Slice[*Run] `json:"finally"`
+}
+
+// taskInputs returns the sources, definition, values, and environment of a
+// task.
+//
+// Every value the task references is evaluated first, including those only
+// used by run items that end up being skipped.
+func (t *Task) taskInputs(c Context, stats *statCache) (taskInputs, error) {
+	var ignore *gitignore
+	if t.Gitignore {
+		ignore = newGitignore(os.DirFS(c.Dir()))
+	}
+
+	sources, err := dirManifest("source", c.Dir(), t.Source, ignore, stats)
+	if err != nil {
+		return taskInputs{}, err
+	}
+
+	if t.isCacheable() {
+		if err := t.vars.resolve(c, t); err != nil {
+			return taskInputs{}, err
+		}
+	}
+
+	definition, err := json.Marshal(taskDefinition{
+		Name:    t.Name,
+		Target:  t.Target,
+		Run:     t.RunList,
+		Finally: t.Finally,
+	})
+	if err != nil {
+		return taskInputs{}, err
+	}
+
+	env := make(map[string]string, len(t.CacheEnv))
+	for _, name := range t.CacheEnv {
+		// Unset variables are left out, so they differ from empty ones.
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+
+	definitionSum := sha256.Sum256(definition)
+	return taskInputs{
+		Sources:     sources,
+		Definition:  hex.EncodeToString(definitionSum[:]),
+		Vars:        hashValues(t.Vars),
+		Interpreter: c.Interpreter,
+		Env:         hashValues(env),
+	}, nil
+}
+
+// hashValues returns a checksum of each value by name.
+func hashValues(values map[string]string) map[string]string {
+	sums := make(map[string]string, len(values))
+	for name, value := range values {
+		sum := sha256.Sum256([]byte(name + "=" + value))
+		sums[name] = hex.EncodeToString(sum[:])
+	}
+
+	return sums
+}
+
+// key returns a checksum of the inputs.
+func (in taskInputs) key() (string, error) {
+	// The sources are replaced by their checksum, so that the key does not
+	// depend on how the manifest is encoded.
+	data, err := json.Marshal(struct {
+		taskInputs
+
+		Sources string `json:"sources"`
+	}{in, in.Sources.checksum()})
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(taskCacheDir, filename), nil
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
 }
 
 // taskCacheDir returns the file path specific to this task.
@@ -161,25 +303,36 @@ func tuskCacheDir() (string, error) {
 }
 
 // outputChecksum returns a checksum for the output of a task.
-func (t *Task) outputChecksum(c Context) (string, error) {
-	filename, err := dirChecksum("target", os.DirFS(c.Dir()), t.Target)
+func (t *Task) outputChecksum(c Context, stats *statCache) (string, error) {
+	targets, err := t.outputManifest(c, stats)
+	if err != nil || targets == nil {
+		return "", err
+	}
+
+	return targets.checksum(), nil
+}
+
+// outputManifest returns the checksum of each target file of a task, or nil if
+// any of the target patterns do not match a file.
+func (t *Task) outputManifest(c Context, stats *statCache) (manifest, error) {
+	targets, err := dirManifest("target", c.Dir(), t.Target, nil, stats)
 	var pnfe *patternNotFoundError
 	switch {
 	case errors.As(err, &pnfe):
-		return "", nil
+		return nil, nil
 	case err != nil:
-		return "", err
+		return nil, err
 	}
 
-	return filename, nil
+	return targets, nil
 }
 
-func (t *Task) cache(ctx Context, cachePath string) error {
+func (t *Task) cache(ctx Context, cachePath string, inputs taskInputs, stats *statCache) error {
 	if !t.isCacheable() {
 		return nil
 	}
 
-	outputChecksum, err := t.outputChecksum(ctx)
+	targets, err := t.outputManifest(ctx, stats)
 	if err != nil {
 		return err
 	}
@@ -188,7 +341,29 @@ func (t *Task) cache(ctx Context, cachePath string) error {
 		return err
 	}
 
-	if err := os.WriteFile(cachePath, []byte(outputChecksum), 0o600); err != nil {
+	var target string
+	if targets != nil {
+		target = targets.checksum()
+	}
+
+	cfgPath, err := filepath.Abs(ctx.CfgPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		Version: cacheVersion,
+		Config:  cfgPath,
+		Task:    t.Name,
+		Target:  target,
+		Targets: targets,
+		Inputs:  inputs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(cachePath, data, 0o600); err != nil {
 		return err
 	}
 
@@ -218,20 +393,63 @@ type result struct {
 	sum  []byte
 }
 
-func dirChecksum(kind string, dir fs.FS, patterns []string) (string, error) {
+// manifest holds the checksum of each file in a set, by path.
+type manifest map[string]string
+
+// checksum returns a single checksum of every file in the manifest.
+func (m manifest) checksum() string {
+	paths := slices.Sorted(maps.Keys(m))
+
+	// Each path and sum is followed by a null byte, which cannot appear in
+	// either, so no two sets of files are written the same way.
+	h := sha256.New()
+	for _, path := range paths {
+		h.Write([]byte(path + "\x00" + m[path] + "\x00"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dirChecksum returns a checksum of the files in a directory that match any of
+// the patterns, covering the path, mode, and contents of each file. Files that
+// have not changed according to the stat cache are not hashed again.
+func dirChecksum(
+	kind string,
+	root string,
+	patterns []string,
+	ignore *gitignore,
+	stats *statCache,
+) (string, error) {
+	m, err := dirManifest(kind, root, patterns, ignore, stats)
+	if err != nil {
+		return "", err
+	}
+
+	return m.checksum(), nil
+}
+
+// dirManifest returns the checksum of each file in a directory that matches
+// any of the patterns.
+func dirManifest(
+	kind string,
+	root string,
+	patterns []string,
+	ignore *gitignore,
+	stats *statCache,
+) (manifest, error) {
 	g, ctx := errgroup.WithContext(context.Background())
 	numWorkers := runtime.GOMAXPROCS(0)
 
 	entries := make(chan entry, numWorkers*2)
 	g.Go(func() error {
 		defer close(entries)
-		return walkEntries(ctx, entries, kind, dir, patterns)
+		return walkEntries(ctx, entries, kind, os.DirFS(root), patterns, ignore)
 	})
 
 	results := make(chan result, numWorkers*2)
 	for range numWorkers {
 		g.Go(func() error {
-			return hashEntries(ctx, results, entries)
+			return hashEntries(ctx, results, root, entries, stats)
 		})
 	}
 	go func() {
@@ -239,44 +457,49 @@ func dirChecksum(kind string, dir fs.FS, patterns []string) (string, error) {
 		close(results)
 	}()
 
-	var resultList []result //nolint:prealloc
+	m := make(manifest)
 	for result := range results {
-		resultList = append(resultList, result)
+		m[result.path] = hex.EncodeToString(result.sum)
 	}
 
 	if err := g.Wait(); err != nil {
-		return "", err
-	}
-
-	slices.SortFunc(resultList, func(a, b result) int {
-		return cmp.Compare(a.path, b.path)
-	})
-
-	h := fnv.New64a()
-	for _, result := range resultList {
-		h.Write(result.sum)
+		return nil, err
 	}
 
-	return encodeToString(h), nil
+	return m, nil
 }
 
-// walkEntries iterates over a set of files and writes them to entries.
+// walkEntries iterates over a set of files and writes them to entries. Files
+// that match an exclusion pattern or are ignored by git are skipped.
 func walkEntries(
 	ctx context.Context,
 	entries chan<- entry,
 	kind string,
 	dir fs.FS,
 	patterns []string,
+	ignore *gitignore,
 ) error {
-	for _, glob := range patterns {
+	include, exclude := splitPatterns(patterns)
+	isSkipped := func(name string) (bool, error) {
+		if isExcluded(name, exclude) {
+			return true, nil
+		}
+		return ignore.isIgnored(name)
+	}
+
+	for _, glob := range include {
 		count := 0
 		err := doublestar.GlobWalk(
 			dir,
 			filepath.Clean(glob),
-			func(path string, d fs.DirEntry) error {
+			func(name string, d fs.DirEntry) error {
+				if skip, err := isSkipped(name); skip || err != nil {
+					return err
+				}
+
 				count++
 				select {
-				case entries <- entry{path, d}:
+				case entries <- entry{name, d}:
 				case <-ctx.Done():
 					return ctx.Err()
 				}
@@ -297,18 +520,73 @@ func walkEntries(
 	return nil
 }
 
+// splitPatterns separates the patterns that select files from those, prefixed
+// with "!", that exclude them.
+func splitPatterns(patterns []string) (include []string, exclude []string) {
+	for _, pattern := range patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, path.Clean(filepath.ToSlash(negated)))
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	return include, exclude
+}
+
+// isExcluded reports whether a file, or any directory containing it, matches
+// any of the exclusion patterns.
+func isExcluded(name string, exclude []string) bool {
+	for _, pattern := range exclude {
+		for p := name; p != "."; p = path.Dir(p) {
+			if ok, err := doublestar.Match(pattern, p); err == nil && ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validatePatterns checks that a list of patterns selects files, rather than
+// only excluding them.
+func validatePatterns(kind string, patterns []string) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	if include, _ := splitPatterns(patterns); len(include) == 0 {
+		return fmt.Errorf("task %s must contain a pattern that is not an exclusion", kind)
+	}
+
+	return nil
+}
+
 // hashEntries iterates over entries and hashes the files into results.
 func hashEntries(
 	ctx context.Context,
 	results chan<- result,
+	root string,
 	entries <-chan entry,
+	stats *statCache,
 ) error {
+	fsys := os.DirFS(root)
 	buf := make([]byte, 1024*1024)
 	for entry := range entries {
-		sum, err := hashFile(entry.path, entry.d, buf)
+		info, err := entry.d.Info()
 		if err != nil {
 			return err
 		}
+
+		sum, ok := stats.lookup(entry.path, info)
+		if !ok {
+			sum, err = hashFile(fsys, root, entry.path, info, buf)
+			if err != nil {
+				return err
+			}
+			stats.store(entry.path, info, sum)
+		}
+
 		select {
 		case results <- result{entry.path, sum}:
 		case <-ctx.Done():
@@ -318,18 +596,32 @@ func hashEntries(
 	return nil
 }
 
-func hashFile(path string, d fs.DirEntry, buf []byte) ([]byte, error) {
-	h := fnv.New64a()
-	if _, err := io.WriteString(h, path); err != nil {
+// hashFile returns a checksum of a file's mode and contents. For symlinks, the
+// target of the link is included as well as the contents of the file it points
+// to, if any.
+func hashFile(fsys fs.FS, root string, path string, info fs.FileInfo, buf []byte) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.WriteString(h, info.Mode().String()+"\x00"); err != nil {
 		return nil, err
 	}
 
-	if _, err := io.WriteString(h, d.Type().String()); err != nil {
-		return nil, err
+	if info.Mode().Type() == fs.ModeSymlink {
+		target, err := os.Readlink(filepath.Join(root, path))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(h, target+"\x00"); err != nil {
+			return nil, err
+		}
 	}
 
-	file, err := os.Open(path)
-	if err != nil {
+	file, err := fsys.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && info.Mode().Type() == fs.ModeSymlink:
+		// Broken links are hashed by their target alone.
+		return h.Sum(nil), nil
+	case err != nil:
 		return nil, err
 	}
 	defer file.Close() //nolint:errcheck
@@ -341,6 +633,43 @@ func hashFile(path string, d fs.DirEntry, buf []byte) ([]byte, error) {
 	return h.Sum(nil), nil
 }
 
+// encodeToString returns the sum of a hash as a string that is safe to use as
+// a file name.
 func encodeToString(h hash.Hash) string {
-	return base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFileAtomic writes data to a file, replacing it only once the data has
+// been written in full. An interrupted write leaves the file unchanged.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	return copyFileAtomic(name, bytes.NewReader(data), perm)
+}
+
+// copyFileAtomic copies the contents of a reader to a file in the same way as
+// writeFileAtomic.
+func copyFileAtomic(name string, r io.Reader, perm os.FileMode) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()           //nolint:errcheck
+			os.Remove(f.Name()) //nolint:errcheck
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
 }
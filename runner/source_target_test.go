@@ -1,12 +1,19 @@
 package runner
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"testing"
 
 	"github.com/rliebz/ghost"
 	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
 )
 
 func TestCleanCache(t *testing.T) {
@@ -118,3 +125,353 @@ func TestCleanTaskCache_no_cfg_path(t *testing.T) {
 	err := CleanTaskCache("", "foo")
 	g.Should(be.ErrorEqual(err, "no config file found"))
 }
+
+func TestDirChecksum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test is for unix file permissions and symlinks")
+	}
+
+	g := ghost.New(t)
+
+	dir := t.TempDir()
+	write := func(name, data string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600)
+		g.NoError(err)
+	}
+	checksum := func() string {
+		sum, err := dirChecksum("source", dir, []string{"*"}, nil, nil)
+		g.NoError(err)
+		return sum
+	}
+
+	write("a.txt", "data a")
+	write("b.txt", "data b")
+	err := os.Symlink("a.txt", filepath.Join(dir, "link.txt"))
+	g.NoError(err)
+
+	original := checksum()
+	g.Should(be.Equal(len(original), 64))
+	g.Should(be.Equal(checksum(), original))
+
+	err = os.Chmod(filepath.Join(dir, "a.txt"), 0o700)
+	g.NoError(err)
+	g.Should(be.True(checksum() != original))
+
+	err = os.Chmod(filepath.Join(dir, "a.txt"), 0o600)
+	g.NoError(err)
+	g.Should(be.Equal(checksum(), original))
+
+	err = os.Remove(filepath.Join(dir, "link.txt"))
+	g.NoError(err)
+	err = os.Symlink("b.txt", filepath.Join(dir, "link.txt"))
+	g.NoError(err)
+	g.Should(be.True(checksum() != original))
+
+	err = os.Remove(filepath.Join(dir, "link.txt"))
+	g.NoError(err)
+	err = os.Symlink("a.txt", filepath.Join(dir, "link.txt"))
+	g.NoError(err)
+	g.Should(be.Equal(checksum(), original))
+
+	// Swapping contents between files changes the checksum.
+	write("a.txt", "data b")
+	write("b.txt", "data a")
+	g.Should(be.True(checksum() != original))
+}
+
+func TestTask_isUpToDate_cache_version(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	err := os.WriteFile("input.txt", []byte("data a"), 0o600)
+	g.NoError(err)
+	err = os.WriteFile("output.txt", []byte("data b"), 0o600)
+	g.NoError(err)
+
+	task := Task{
+		Name:   "my-task",
+		Source: marshal.Slice[string]{"input.txt"},
+		Target: marshal.Slice[string]{"output.txt"},
+	}
+	ctx := Context{CfgPath: "tusk.yml"}
+
+	inputs, err := task.taskInputs(ctx, nil)
+	g.NoError(err)
+
+	cachePath, err := task.taskInputCachePath(ctx, inputs)
+	g.NoError(err)
+
+	err = task.cache(ctx, cachePath, inputs, nil)
+	g.NoError(err)
+
+	upToDate, err := task.isUpToDate(ctx, cachePath, nil)
+	g.NoError(err)
+	g.Should(be.True(upToDate))
+
+	target, err := task.outputChecksum(ctx, nil)
+	g.NoError(err)
+
+	for _, data := range []string{
+		target,
+		fmt.Sprintf(`{"version": %d, "target": %q}`, cacheVersion-1, target),
+	} {
+		err = os.WriteFile(cachePath, []byte(data), 0o600)
+		g.NoError(err)
+
+		upToDate, err = task.isUpToDate(ctx, cachePath, nil)
+		g.NoError(err)
+		g.Should(be.False(upToDate))
+	}
+}
+
+func TestTask_Execute_cache_key(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	cfgText := `
+tasks:
+  build:
+    options:
+      release:
+        type: bool
+    source: input.txt
+    target: output.txt
+    cache-env: TUSK_TEST_TARGET
+    run: echo ${release} >> runs.txt && touch output.txt
+`
+
+	execute := func(flags map[string]string) {
+		t.Helper()
+
+		cfg, err := ParseComplete(&ParseConfig{
+			CfgPath:  "tusk.yml",
+			CfgText:  []byte(cfgText),
+			Flags:    flags,
+			TaskName: "build",
+		})
+		g.NoError(err)
+
+		err = cfg.Tasks["build"].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+		g.NoError(err)
+	}
+
+	execute(nil)
+	execute(nil)
+	execute(map[string]string{"release": "true"})
+	execute(map[string]string{"release": "true"})
+
+	t.Setenv("TUSK_TEST_TARGET", "linux")
+	execute(nil)
+	execute(nil)
+
+	t.Setenv("TUSK_TEST_TARGET", "")
+	execute(nil)
+
+	got, err := os.ReadFile("runs.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "false\ntrue\nfalse\nfalse\n"))
+}
+
+func TestTask_Execute_cache_key_sub_task(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	cfgText := `
+options:
+  env:
+    default: dev
+tasks:
+  generate:
+    run: echo ${env} > generated.txt
+  build:
+    source: input.txt
+    target: output.txt
+    run:
+      - task: generate
+      - echo build >> runs.txt && touch output.txt
+  main:
+    run:
+      - task: generate
+      - task: build
+`
+
+	execute := func(taskName string) {
+		t.Helper()
+
+		cfg, err := ParseComplete(&ParseConfig{
+			CfgPath:  "tusk.yml",
+			CfgText:  []byte(cfgText),
+			TaskName: taskName,
+		})
+		g.NoError(err)
+
+		err = cfg.Tasks[taskName].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+		g.NoError(err)
+	}
+
+	// The task is up to date however it is run, even though the values used
+	// by its sub-tasks have been evaluated by the time it is called from main.
+	execute("build")
+	execute("main")
+	execute("build")
+
+	got, err := os.ReadFile("runs.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "build\n"))
+}
+
+func TestWalkEntries_exclude(t *testing.T) {
+	g := ghost.New(t)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".git/HEAD":       "ref: refs/heads/main",
+		".gitignore":      "/gen/*\n!gen/keep.go\n*.log\n",
+		"a.go":            "package a",
+		"a_test.go":       "package a",
+		"debug.log":       "debug",
+		"gen/keep.go":     "package gen",
+		"gen/x.go":        "package gen",
+		"sub/.gitignore":  "local.go\n",
+		"sub/local.go":    "package sub",
+		"sub/other.go":    "package sub",
+		"vendor/v/v.go":   "package v",
+		"vendor/v/v.txt":  "v",
+		"vendor/vendored": "v",
+	} {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700)
+		g.NoError(err)
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		g.NoError(err)
+	}
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		gitignore bool
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:     "exclude directory",
+			patterns: []string{"**/*.go", "!vendor"},
+			want: []string{
+				"a.go", "a_test.go", "gen/keep.go", "gen/x.go", "sub/local.go", "sub/other.go",
+			},
+		},
+		{
+			name:     "exclude globs",
+			patterns: []string{"!**/*_test.go", "**/*.go", "!gen/**", "!vendor/**"},
+			want:     []string{"a.go", "sub/local.go", "sub/other.go"},
+		},
+		{
+			name:     "every match excluded",
+			patterns: []string{"vendor/**/*.go", "!vendor/v"},
+			wantErr:  "no source files found matching pattern: vendor/**/*.go",
+		},
+		{
+			name:      "gitignore",
+			patterns:  []string{"**/*"},
+			gitignore: true,
+			want: []string{
+				".gitignore",
+				"a.go",
+				"a_test.go",
+				"gen/keep.go",
+				"sub/.gitignore",
+				"sub/other.go",
+				"vendor/v/v.go",
+				"vendor/v/v.txt",
+				"vendor/vendored",
+			},
+		},
+		{
+			name:      "gitignore and exclude",
+			patterns:  []string{"**/*.go", "!**/*_test.go"},
+			gitignore: true,
+			want:      []string{"a.go", "gen/keep.go", "sub/other.go", "vendor/v/v.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			fsys := os.DirFS(dir)
+
+			var ignore *gitignore
+			if tt.gitignore {
+				ignore = newGitignore(fsys)
+			}
+
+			entries := make(chan entry, 100)
+			err := walkEntries(t.Context(), entries, "source", fsys, tt.patterns, ignore)
+			close(entries)
+			if tt.wantErr != "" {
+				g.Should(be.ErrorEqual(err, tt.wantErr))
+				return
+			}
+			g.NoError(err)
+
+			var got []string
+			for e := range entries {
+				got = append(got, e.path)
+			}
+			slices.Sort(got)
+
+			g.Should(be.DeepEqual(got, tt.want))
+		})
+	}
+}
+
+func TestTask_Execute_cache_values_hidden(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	t.Setenv("TUSK_TEST_TOKEN", "env-secret")
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgPath: "tusk.yml",
+		CfgText: []byte(`
+tasks:
+  build:
+    options:
+      token:
+        secret: true
+    source: input.txt
+    target: output.txt
+    cache-env: TUSK_TEST_TOKEN
+    run: echo ${token} > /dev/null && touch output.txt
+`),
+		Flags:    map[string]string{"token": "hunter2"},
+		TaskName: "build",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["build"].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+	g.NoError(err)
+
+	state := dirState(t, cacheDir)
+	g.Must(be.True(len(state) > 0))
+	for _, content := range state {
+		g.Should(be.Not(be.StringContaining(content, "hunter2")))
+		g.Should(be.Not(be.StringContaining(content, "env-secret")))
+	}
+}
@@ -0,0 +1,174 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// statCacheVersion is the version of the stat cache format. Changing it
+// invalidates every existing stat cache.
+const statCacheVersion = 1
+
+// statCacheFile is the name of the stat cache within a task's cache dir.
+const statCacheFile = "stat.json"
+
+// statCache records the hash of each file along with its size, modification
+// time, and inode, so that files that have not changed since they were last
+// hashed do not need to be read again.
+//
+// A nil statCache is valid and hashes every file.
+type statCache struct {
+	path string
+
+	// started is when hashing started. Files modified since then might be
+	// modified again without their stats changing, so they are not cached.
+	started time.Time
+
+	mu       sync.Mutex
+	previous map[string]statEntry
+	current  map[string]statEntry
+}
+
+// fileStat is the information about a file that changes with its contents.
+type fileStat struct {
+	Size    int64       `json:"size"`
+	ModTime int64       `json:"mtime"`
+	Inode   uint64      `json:"inode"`
+	Mode    fs.FileMode `json:"mode"`
+}
+
+func newFileStat(info fs.FileInfo) fileStat {
+	return fileStat{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Inode:   fileInode(info),
+		Mode:    info.Mode(),
+	}
+}
+
+// statEntry is the stat and hash of a single file.
+type statEntry struct {
+	fileStat
+
+	Sum []byte `json:"sum"`
+}
+
+// statCacheData is the content of a stat cache file.
+type statCacheData struct {
+	Version int                  `json:"version"`
+	Files   map[string]statEntry `json:"files"`
+}
+
+// loadStatCache returns the stat cache for a task, or nil if the task is not
+// cacheable. With full hashing, previously recorded stats are ignored, but the
+// stats of the files hashed are still recorded.
+func (t *Task) loadStatCache(ctx Context) (*statCache, error) {
+	if !t.isCacheable() {
+		return nil, nil
+	}
+
+	c, err := loadStatCache(ctx.CfgPath, t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.FullHash {
+		c.previous = nil
+	}
+
+	return c, nil
+}
+
+// loadStatCache reads the stat cache for a task. Files that are missing,
+// cannot be parsed, or were written by another version are treated as empty.
+func loadStatCache(cfgPath string, taskName string) (*statCache, error) {
+	cacheDir, err := taskCacheDir(cfgPath, taskName)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &statCache{
+		path:    filepath.Join(cacheDir, statCacheFile),
+		started: timeNow(),
+		current: make(map[string]statEntry),
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached statCacheData
+	if json.Unmarshal(data, &cached) == nil && cached.Version == statCacheVersion {
+		c.previous = cached.Files
+	}
+
+	return c, nil
+}
+
+// lookup returns the previous hash of a file, if its stats have not changed.
+func (c *statCache) lookup(path string, info fs.FileInfo) ([]byte, bool) {
+	if c == nil || !c.isCacheable(info) {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.previous[path]
+	if !ok || entry.fileStat != newFileStat(info) {
+		return nil, false
+	}
+
+	c.current[path] = entry
+	return entry.Sum, true
+}
+
+// store records the hash of a file.
+func (c *statCache) store(path string, info fs.FileInfo, sum []byte) {
+	if c == nil || !c.isCacheable(info) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.current[path] = statEntry{fileStat: newFileStat(info), Sum: sum}
+}
+
+// isCacheable reports whether a file's hash can be reused based on its stats.
+// The contents a symlink points to can change without the link's own stats
+// changing, and files modified after hashing started may not be detected.
+func (c *statCache) isCacheable(info fs.FileInfo) bool {
+	return info.Mode().IsRegular() && info.ModTime().Before(c.started.Truncate(time.Second))
+}
+
+// save writes the stats of every file looked up or stored since the cache was
+// loaded.
+func (c *statCache) save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(statCacheData{Version: statCacheVersion, Files: c.current})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.path, data, 0o600)
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/marshal"
+)
+
+func TestDirChecksum_stat_cache(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xtesting.UseTempDir(t)
+
+	// Rewrite the file in place with the same size and mtime, so that only a
+	// full hash can detect the change.
+	modTime := time.Now().Add(-time.Hour)
+	writeFile := func(content string) {
+		err := os.WriteFile("a.txt", []byte(content), 0o600)
+		g.NoError(err)
+		err = os.Chtimes("a.txt", modTime, modTime)
+		g.NoError(err)
+	}
+
+	task := &Task{
+		Name:   "build",
+		Source: marshal.Slice[string]{"a.txt"},
+		Target: marshal.Slice[string]{"b.txt"},
+	}
+
+	checksum := func(ctx Context) string {
+		stats, err := task.loadStatCache(ctx)
+		g.NoError(err)
+
+		sum, err := dirChecksum("source", ".", task.Source, nil, stats)
+		g.NoError(err)
+
+		err = stats.save()
+		g.NoError(err)
+
+		return sum
+	}
+
+	ctx := Context{CfgPath: "tusk.yml"}
+
+	writeFile("one")
+	first := checksum(ctx)
+
+	writeFile("two")
+	g.Should(be.Equal(checksum(ctx), first))
+
+	ctx.FullHash = true
+	full := checksum(ctx)
+	g.Should(be.Not(be.Equal(full, first)))
+
+	ctx.FullHash = false
+	g.Should(be.Equal(checksum(ctx), full))
+}
+
+func TestStatCache_recently_modified(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xtesting.UseTempDir(t)
+
+	err := os.WriteFile("a.txt", []byte("one"), 0o600)
+	g.NoError(err)
+
+	stats, err := loadStatCache("tusk.yml", "build")
+	g.NoError(err)
+
+	_, err = dirChecksum("source", ".", []string{"a.txt"}, nil, stats)
+	g.NoError(err)
+
+	g.Should(be.MapLen(stats.current, 0))
+}
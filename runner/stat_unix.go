@@ -0,0 +1,16 @@
+//go:build unix
+
+package runner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileInode returns the inode number of a file, if known.
+func fileInode(info fs.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino) //nolint:unconvert // Ino is not uint64 on every platform
+	}
+	return 0
+}
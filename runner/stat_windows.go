@@ -0,0 +1,12 @@
+//go:build windows
+
+package runner
+
+import "io/fs"
+
+// fileInode returns the inode number of a file, if known. Windows has no
+// inode numbers available from a stat, so the size and modification time alone
+// identify a change.
+func fileInode(fs.FileInfo) uint64 {
+	return 0
+}
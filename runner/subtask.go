@@ -7,6 +7,7 @@ type SubTask struct {
 	Name    string
 	Args    marshal.Slice[string]
 	Options map[string]string
+	Retry   *Retry `yaml:"retry,omitempty" json:"-"`
 }
 
 // UnmarshalYAML allows unmarshaling a string to represent the subtask name.
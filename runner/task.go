@@ -1,15 +1,20 @@
 package runner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/rliebz/tusk/marshal"
 )
 
+// timeNow allows overwriting during tests.
+var timeNow = time.Now
+
 // executionState indicates whether a task is "running" or "finally".
 type executionState int
 
@@ -33,9 +38,42 @@ type Task struct {
 	Source marshal.Slice[string] `yaml:"source"`
 	Target marshal.Slice[string] `yaml:"target"`
 
+	// CacheEnv are environment variables whose values are part of the task
+	// cache, so that changing them runs the task again.
+	CacheEnv marshal.Slice[string] `yaml:"cache-env,omitempty"`
+
+	// Gitignore skips sources and watched files that are ignored by git.
+	// Targets are often ignored, so they are never skipped.
+	Gitignore bool `yaml:"gitignore,omitempty"`
+
+	// Watch are the patterns of files that run the task again when they
+	// change in watch mode. The sources are watched if unset.
+	Watch marshal.Slice[string] `yaml:"watch,omitempty"`
+
+	// ContinueOnError runs every run item, even after failures. The task still
+	// fails once all run items have completed.
+	ContinueOnError bool `yaml:"continue-on-error,omitempty"`
+
+	// Timeout is the maximum time the task may run before its commands are
+	// terminated.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// GracePeriod is how long to wait after a command in the task is asked to
+	// terminate before it is killed, unless the command sets its own.
+	GracePeriod time.Duration `yaml:"grace-period,omitempty"`
+
+	// Log is the path of a file, relative to the config file, that the task's
+	// output is copied to while it runs.
+	Log string `yaml:"log,omitempty"`
+
 	// Computed members not specified in yaml file
 	Name string            `yaml:"-"`
 	Vars map[string]string `yaml:"-"`
+	vars *variables
+
+	// cacheConfig is set for the task being run, which prunes the cache once
+	// it has finished.
+	cacheConfig *CacheConfig
 }
 
 // UnmarshalYAML unmarshals and assigns names to options.
@@ -95,12 +133,12 @@ func (t *Task) UnmarshalYAML(unmarshal func(any) error) error {
 
 // isValid checks whether a given task definition is valid.
 func (t *Task) isValid() error {
-	if len(t.Source) > 0 && len(t.Target) == 0 {
-		return errors.New("task source cannot be defined without target")
+	if err := t.isValidCache(); err != nil {
+		return err
 	}
 
-	if len(t.Target) > 0 && len(t.Source) == 0 {
-		return errors.New("task target cannot be defined without source")
+	if err := validatePatterns("watch", t.Watch); err != nil {
+		return err
 	}
 
 	for _, o := range t.Options {
@@ -116,6 +154,32 @@ func (t *Task) isValid() error {
 	return nil
 }
 
+// isValidCache checks whether the source, target, and cache settings of a task
+// are valid.
+func (t *Task) isValidCache() error {
+	if len(t.Source) > 0 && len(t.Target) == 0 {
+		return errors.New("task source cannot be defined without target")
+	}
+
+	if len(t.Target) > 0 && len(t.Source) == 0 {
+		return errors.New("task target cannot be defined without source")
+	}
+
+	if len(t.CacheEnv) > 0 && len(t.Source) == 0 {
+		return errors.New("task cache-env cannot be defined without source and target")
+	}
+
+	if t.Gitignore && len(t.Source) == 0 {
+		return errors.New("task gitignore cannot be defined without source and target")
+	}
+
+	if err := validatePatterns("source", t.Source); err != nil {
+		return err
+	}
+
+	return validatePatterns("target", t.Target)
+}
+
 // AllRunItems returns all run items referenced, including `run` and `finally`.
 func (t *Task) AllRunItems() marshal.Slice[*Run] {
 	return append(t.RunList, t.Finally...)
@@ -137,39 +201,188 @@ func (t *Task) Dependencies() []string {
 }
 
 // Execute runs the Run scripts in the task.
-func (t *Task) Execute(ctx Context) (err error) {
+//
+// A task is executed at most once per invocation. Executing a task that has
+// already been executed returns the result of the first execution.
+func (t *Task) Execute(ctx Context) error {
+	if ctx.executions == nil {
+		ctx.executions = new(executions)
+		ctx.logs = new(logFiles)
+		defer ctx.logs.close(ctx.Logger)
+		defer t.pruneCache(ctx)
+	}
+
+	exec, first := ctx.executions.start(t)
+	if !first {
+		ctx.Logger.PrintTaskSkipped(t.Name, "task has already been run")
+		return exec.wait()
+	}
+
+	err := t.execute(ctx)
+	exec.finish(err)
+	return err
+}
+
+// subTasks returns the task and every sub-task it may run, directly or
+// through other sub-tasks.
+func (t *Task) subTasks() []*Task {
+	tasks := []*Task{t}
+	seen := map[*Task]bool{t: true}
+	for i := 0; i < len(tasks); i++ {
+		for _, r := range tasks[i].AllRunItems() {
+			for _, sub := range r.Tasks {
+				if !seen[sub] {
+					seen[sub] = true
+					tasks = append(tasks, sub)
+				}
+			}
+		}
+	}
+
+	return tasks
+}
+
+func (t *Task) execute(ctx Context) (err error) {
 	ctx = ctx.WithTask(t)
 
-	cachePath, err := t.taskInputCachePath(ctx)
+	store, err := newArtifactStore(ctx.ArtifactCache)
+	if err != nil {
+		return fmt.Errorf("opening artifact cache: %w", err)
+	}
+
+	stats, err := t.loadStatCache(ctx)
+	if err != nil {
+		return fmt.Errorf("checking cache: %w", err)
+	}
+
+	inputs, err := t.taskInputs(ctx, stats)
 	if err != nil {
 		return err
 	}
 
-	isUpToDate, err := t.isUpToDate(ctx, cachePath)
+	cachePath, err := t.taskInputCachePath(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	isUpToDate, err := t.checkUpToDate(ctx, cachePath, stats)
 	if err != nil {
 		return fmt.Errorf("checking cache: %w", err)
 	}
 	if isUpToDate {
-		ctx.Logger.PrintTaskSkipped(t.Name, "all targets up to date")
-		return nil
+		ctx.Logger.PrintTaskUpToDate(t.Name)
+		if ctx.DryRun {
+			return nil
+		}
+		if err := touchCacheEntry(cachePath); err != nil {
+			return fmt.Errorf("checking cache: %w", err)
+		}
+		return stats.save()
+	}
+
+	if t.restoreArtifact(ctx, store, cachePath, inputs, stats) {
+		ctx.Logger.PrintTaskRestored(t.Name)
+		return stats.save()
 	}
 
+	ctx, flushLog, err := t.withLog(ctx)
+	if err != nil {
+		return fmt.Errorf("opening task log: %w", err)
+	}
+	defer flushLog()
+
+	start := timeNow()
 	ctx.Logger.PrintTask(t.Name)
 
-	defer ctx.Logger.PrintTaskCompleted(t.Name)
+	ctx, cancel := withTimeout(ctx, t.Timeout, "task", t.Name)
+	defer cancel()
+
+	if ctx.DryRun {
+		if err := t.vars.resolveNames(ctx, t.valueNames()...); err != nil {
+			return err
+		}
+		ctx.Logger.PrintTaskValues(t.Name, t.valueNames(), t.Vars, t.secretNames())
+	}
+
+	// Background commands are stopped before the finally clause runs, and
+	// again after it, in case the finally clause started any.
+	ctx.background = new(backgroundGroup)
+
+	defer func() { ctx.Logger.PrintTaskCompleted(t.Name, timeNow().Sub(start)) }()
+	defer ctx.background.stop()
 	defer t.runFinally(ctx, &err)
+	defer ctx.background.stop()
 
+	var failed failures
 	for _, r := range t.RunList {
 		if err := t.run(ctx, r, stateRunning); err != nil {
-			return err
+			if !t.continuesOnError(r) {
+				return failed.with(err)
+			}
+			failed.add(err)
 		}
 	}
 
-	if err := t.cache(ctx, cachePath); err != nil {
+	if err := failed.err(); err != nil {
+		return err
+	}
+
+	if ctx.DryRun {
+		return nil
+	}
+
+	if err := t.cache(ctx, cachePath, inputs, stats); err != nil {
 		return fmt.Errorf("caching task: %w", err)
 	}
 
-	return nil
+	t.storeArtifact(ctx, store, cachePath)
+
+	return stats.save()
+}
+
+// checkUpToDate reports whether the task is up to date. During a dry
+// run, option values that depend on commands are only placeholders, so a task
+// that uses them is reported as such and treated as out of date.
+func (t *Task) checkUpToDate(
+	ctx Context, cachePath string, stats *statCache,
+) (bool, error) {
+	if ctx.DryRun && t.isCacheable() && t.vars.hasPlaceholders() {
+		ctx.Logger.Info(fmt.Sprintf(
+			"Cannot tell whether task %q is up to date, since its options depend on commands",
+			t.Name,
+		))
+		return false, nil
+	}
+
+	return t.isUpToDate(ctx, cachePath, stats)
+}
+
+// continuesOnError reports whether the task continues after a run item fails.
+func (t *Task) continuesOnError(r *Run) bool {
+	return t.ContinueOnError || r.ContinueOnError
+}
+
+// valueNames returns the names of the task's args and options, in order.
+func (t *Task) valueNames() []string {
+	names := make([]string, 0, len(t.Args)+len(t.Options))
+	for _, a := range t.Args {
+		names = append(names, a.Name)
+	}
+	for _, o := range t.Options {
+		names = append(names, o.Name)
+	}
+	return names
+}
+
+// secretNames returns the names of the task's secret options.
+func (t *Task) secretNames() []string {
+	var names []string
+	for _, o := range t.Options {
+		if o.Secret {
+			names = append(names, o.Name)
+		}
+	}
+	return names
 }
 
 func (t *Task) runFinally(ctx Context, err *error) {
@@ -179,6 +392,9 @@ func (t *Task) runFinally(ctx Context, err *error) {
 
 	ctx.Logger.PrintTaskFinally(t.Name)
 
+	// Cleanup should happen even if the run was cancelled.
+	ctx = ctx.WithContext(context.WithoutCancel(ctx.Context()))
+
 	for _, r := range t.Finally {
 		if rerr := t.run(ctx, r, stateFinally); rerr != nil {
 			// Do not overwrite existing errors
@@ -192,7 +408,27 @@ func (t *Task) runFinally(ctx Context, err *error) {
 
 // run executes a Run struct.
 func (t *Task) run(ctx Context, r *Run, s executionState) error {
-	if ok, err := r.shouldRun(ctx, t.Vars); !ok || err != nil {
+	// The conditions are checked before the values for the rest of the run item
+	// are resolved, so options that are only used by skipped items never are.
+	if err := t.vars.resolve(ctx, &r.When); err != nil {
+		return err
+	}
+
+	checked, err := r.interpolate(t.Vars)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := checked.shouldRun(ctx, t.Vars); !ok || err != nil {
+		return err
+	}
+
+	if err := t.vars.resolve(ctx, r.Command, r.SetEnvironment, r.Ready); err != nil {
+		return err
+	}
+
+	r, err = r.interpolate(t.Vars)
+	if err != nil {
 		return err
 	}
 
@@ -225,37 +461,166 @@ func shouldBeQuiet(cmd *Command, ctx Context) bool {
 }
 
 func (t *Task) runCommands(ctx Context, r *Run, s executionState) error {
-	for _, command := range r.Command {
-		if !shouldBeQuiet(command, ctx) {
-			switch s {
-			case stateFinally:
-				ctx.Logger.PrintCommandWithParenthetical(command.Print, "finally", ctx.TaskNames()...)
-			default:
-				ctx.Logger.PrintCommand(command.Print, ctx.TaskNames()...)
+	if r.Background {
+		return t.runBackground(ctx, r, s)
+	}
+
+	var failed failures
+	if r.Parallel == 0 {
+		for _, command := range r.Command {
+			out, err := t.runCommand(ctx, command, s)
+			if err != nil {
+				if !t.continuesOnError(r) {
+					return err
+				}
+				failed.add(err)
+				continue
 			}
+			t.capture(command, out)
 		}
 
-		if err := command.exec(ctx); err != nil {
-			ctx.Logger.PrintCommandError(err)
+		return failed.err()
+	}
+
+	// Captures are stored once every command is done, so that the variables
+	// are never written concurrently.
+	outputs := make([]*string, len(r.Command))
+	funcs := make([]func(Context) error, 0, len(r.Command))
+	for i, command := range r.Command {
+		funcs = append(funcs, func(ctx Context) error {
+			ctx.Logger = ctx.Logger.WithPrefix(commandLabel(command))
+			defer ctx.Logger.Flush()
+
+			out, err := t.runCommand(ctx, command, s)
+			if err == nil {
+				outputs[i] = &out
+			}
+			if err != nil && t.continuesOnError(r) {
+				failed.add(err)
+				return nil
+			}
 			return err
+		})
+	}
+
+	err := r.Parallel.runParallel(ctx, funcs)
+	for i, command := range r.Command {
+		if outputs[i] != nil {
+			t.capture(command, *outputs[i])
 		}
 	}
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return failed.err()
+}
+
+// capture stores the output of a command that captures its output, so that
+// later run items can interpolate it.
+func (t *Task) capture(command *Command, out string) {
+	if command.Capture == "" {
+		return
+	}
+
+	if t.Vars == nil {
+		t.Vars = make(map[string]string)
+	}
+	t.Vars[command.Capture] = out
+}
+
+// runCommand runs a command, returning its captured output.
+func (t *Task) runCommand(ctx Context, command *Command, s executionState) (string, error) {
+	if ctx.DryRun || !shouldBeQuiet(command, ctx) {
+		switch s {
+		case stateFinally:
+			ctx.Logger.PrintCommandWithParenthetical(command.Print, "finally", ctx.TaskNames()...)
+		default:
+			ctx.Logger.PrintCommand(command.Print, ctx.TaskNames()...)
+		}
+	}
+
+	if ctx.DryRun {
+		return fmt.Sprintf("$(%s)", command.Exec), nil
+	}
+
+	var out string
+	err := command.Retry.do(ctx, command.Print, func(int) error {
+		start := timeNow()
+		var err error
+		out, err = command.exec(ctx)
+		ctx.Logger.PrintCommandCompleted(
+			command.Print, exitCode(err), timeNow().Sub(start), ctx.TaskNames()...,
+		)
+		return err
+	})
+	if err != nil {
+		// Commands cancelled due to another failure are not worth reporting.
+		var timeoutErr *timeoutError
+		if ctx.Context().Err() == nil || errors.As(err, &timeoutErr) {
+			ctx.Logger.PrintCommandError(err)
+		}
+		return "", &commandError{command: command.Print, tasks: ctx.TaskNames(), err: err}
+	}
+
+	return out, nil
 }
 
 func (t *Task) runSubTasks(ctx Context, r *Run) error {
-	for i := range r.Tasks {
-		if err := r.Tasks[i].Execute(ctx); err != nil {
-			return err
+	var failed failures
+	if r.Parallel == 0 {
+		for i, sub := range r.Tasks {
+			if err := runSubTask(ctx, sub, r.subTaskRetry(i)); err != nil {
+				if !t.continuesOnError(r) {
+					return err
+				}
+				failed.add(err)
+			}
 		}
+
+		return failed.err()
 	}
 
-	return nil
+	funcs := make([]func(Context) error, 0, len(r.Tasks))
+	for i, sub := range r.Tasks {
+		funcs = append(funcs, func(ctx Context) error {
+			ctx.Logger = ctx.Logger.WithPrefix(sub.Name)
+			defer ctx.Logger.Flush()
+
+			err := runSubTask(ctx, sub, r.subTaskRetry(i))
+			if err != nil && t.continuesOnError(r) {
+				failed.add(err)
+				return nil
+			}
+			return err
+		})
+	}
+
+	if err := r.Parallel.runParallel(ctx, funcs); err != nil {
+		return err
+	}
+
+	return failed.err()
+}
+
+// runSubTask executes a sub-task, executing it again for each retry. Each
+// retry also executes again any of its own sub-tasks that have finished, so
+// that the part that failed is run again.
+func runSubTask(ctx Context, sub *Task, retry *Retry) error {
+	return retry.do(ctx, sub.Name, func(attempt int) error {
+		if attempt > 1 {
+			ctx.executions.forget(sub.subTasks())
+		}
+		return sub.Execute(ctx)
+	})
 }
 
 func (t *Task) runEnvironment(ctx Context, r *Run) error {
 	ctx.Logger.PrintEnvironment(r.SetEnvironment)
+	if ctx.DryRun {
+		return nil
+	}
+
 	for key, value := range r.SetEnvironment {
 		if value == nil {
 			if err := os.Unsetenv(key); err != nil {
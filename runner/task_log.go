@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// logFiles are the task log files written during a single invocation. Each file
+// is truncated the first time it is opened, and shared by every execution of
+// tasks that log to it.
+type logFiles struct {
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// open returns the log file at the path, creating it and its directory if
+// needed.
+func (l *logFiles) open(path string) (io.Writer, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if f, ok := l.files[path]; ok {
+		return f, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.files == nil {
+		l.files = make(map[string]*os.File)
+	}
+	l.files[path] = f
+
+	return f, nil
+}
+
+// close closes every log file. Failures are reported as warnings, since the
+// tasks themselves are done.
+func (l *logFiles) close(logger *ui.Logger) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for path, f := range l.files {
+		if err := f.Close(); err != nil {
+			logger.Warn("closing task log:", err)
+		}
+		delete(l.files, path)
+	}
+}
+
+// withLog returns a context whose logger also writes to the task's log file,
+// if it has one. Sub-tasks inherit the logger, so their output is written to
+// their parent's log file as well as their own.
+//
+// The returned function must be called once the task is done, to write any
+// output still buffered for the log file.
+func (t *Task) withLog(ctx Context) (Context, func(), error) {
+	path := t.logPath(ctx)
+	if path == "" || ctx.logs == nil || ctx.DryRun {
+		return ctx, func() {}, nil
+	}
+
+	f, err := ctx.logs.open(path)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+
+	ctx.Logger = ctx.Logger.WithLog(f)
+	return ctx, ctx.Logger.FlushLog, nil
+}
+
+// logPath returns the path of the task's log file, or an empty string if the
+// task is not logged. The task's own log path is relative to the config file,
+// and takes precedence over the log directory.
+func (t *Task) logPath(ctx Context) string {
+	switch {
+	case t.Log != "" && filepath.IsAbs(t.Log):
+		return t.Log
+	case t.Log != "":
+		return filepath.Join(ctx.Dir(), t.Log)
+	case ctx.LogDir != "":
+		return filepath.Join(ctx.LogDir, logFileName(t.Name))
+	default:
+		return ""
+	}
+}
+
+// logFileName returns the name of the log file for a task in the log
+// directory.
+func logFileName(taskName string) string {
+	return strings.NewReplacer("/", "_", `\`, "_").Replace(taskName) + ".log"
+}
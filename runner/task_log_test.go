@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestTask_Execute_log(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	g := ghost.New(t)
+
+	dir := xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+tasks:
+  build:
+    run:
+      - echo building
+      - task: generate
+  generate:
+    log: out/generate.txt
+    run: echo generating >&2
+`),
+		TaskName: "build",
+	})
+	g.NoError(err)
+
+	var out bytes.Buffer
+	ctx := Context{
+		CfgPath: filepath.Join(dir, "tusk.yml"),
+		Logger:  ui.New(ui.Config{Stdout: &out, Stderr: &out}),
+		LogDir:  "logs",
+	}
+	err = cfg.Tasks["build"].Execute(ctx)
+	g.NoError(err)
+
+	build, err := os.ReadFile(filepath.Join("logs", "build.log"))
+	g.NoError(err)
+	g.Should(be.Equal(string(build), out.String()))
+	g.Should(be.StringContaining(string(build), "building\n"))
+	g.Should(be.StringContaining(string(build), "generating\n"))
+
+	generate, err := os.ReadFile(filepath.Join("out", "generate.txt"))
+	g.NoError(err)
+	g.Should(be.StringContaining(string(generate), "generating\n"))
+	g.ShouldNot(be.StringContaining(string(generate), "building"))
+
+	_, err = os.Stat(filepath.Join("logs", "generate.log"))
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestTask_Execute_log_truncates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  []byte(`tasks: {hello: {run: echo hello}}`),
+		TaskName: "hello",
+	})
+	g.NoError(err)
+
+	var out bytes.Buffer
+	ctx := Context{Logger: ui.New(ui.Config{Stdout: &out, Stderr: &out}), LogDir: "logs"}
+	for range 2 {
+		out.Reset()
+		g.NoError(cfg.Tasks["hello"].Execute(ctx))
+	}
+
+	log, err := os.ReadFile(filepath.Join("logs", "hello.log"))
+	g.NoError(err)
+	g.Should(be.Equal(string(log), out.String()))
+	g.Should(be.StringContaining(string(log), "hello\n"))
+}
+
+func TestTask_Execute_log_partial_line(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  []byte(`tasks: {hello: {run: printf partial}}`),
+		TaskName: "hello",
+	})
+	g.NoError(err)
+
+	var out bytes.Buffer
+	ctx := Context{Logger: ui.New(ui.Config{Stdout: &out, Stderr: &out}), LogDir: "logs"}
+	g.NoError(cfg.Tasks["hello"].Execute(ctx))
+
+	log, err := os.ReadFile(filepath.Join("logs", "hello.log"))
+	g.NoError(err)
+	g.Should(be.Equal(string(log), out.String()))
+	g.Should(be.StringContaining(string(log), "partial"))
+}
+
+func TestTask_Execute_log_dry_run(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  []byte(`tasks: {hello: {run: echo hello}}`),
+		TaskName: "hello",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["hello"].Execute(Context{Logger: ui.Noop(), LogDir: "logs", DryRun: true})
+	g.NoError(err)
+
+	_, err = os.Stat("logs")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestLogFileName(t *testing.T) {
+	g := ghost.New(t)
+
+	g.Should(be.Equal(logFileName("build"), "build.log"))
+	g.Should(be.Equal(logFileName("docker:build"), "docker:build.log"))
+	g.Should(be.Equal(logFileName("a/b"), "a_b.log"))
+}
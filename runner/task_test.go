@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rliebz/ghost"
 	"github.com/rliebz/ghost/be"
@@ -529,7 +531,7 @@ func TestTask_Execute_cache(t *testing.T) {
 		})
 	}
 
-	t.Run("readonly cache path", func(t *testing.T) {
+	t.Run("readonly cache path is replaced", func(t *testing.T) {
 		g := ghost.New(t)
 
 		wd := xtesting.UseTempDir(t)
@@ -576,7 +578,10 @@ func TestTask_Execute_cache(t *testing.T) {
 			},
 		}
 
-		cachePath, err := task.taskInputCachePath(ctx)
+		inputs, err := task.taskInputs(ctx, nil)
+		g.NoError(err)
+
+		cachePath, err := task.taskInputCachePath(ctx, inputs)
 		g.NoError(err)
 
 		err = os.MkdirAll(filepath.Dir(cachePath), 0o700)
@@ -585,16 +590,13 @@ func TestTask_Execute_cache(t *testing.T) {
 		err = os.WriteFile(cachePath, []byte("data readonly"), 0o400)
 		g.NoError(err)
 
-		permissionDenied := "permission denied"
-		if runtime.GOOS == "windows" {
-			permissionDenied = "Access is denied."
-		}
-
+		// The cache is replaced rather than written in place.
 		err = task.Execute(ctx)
-		g.Should(be.ErrorEqual(
-			err,
-			fmt.Sprintf("caching task: open %s: %s", cachePath, permissionDenied),
-		))
+		g.NoError(err)
+
+		got, err := os.ReadFile(cachePath)
+		g.NoError(err)
+		g.Should(be.True(string(got) != "data readonly"))
 
 		runCount := strings.Count(buf.String(), "exit 0")
 		if !g.Should(be.Equal(runCount, 1)) {
@@ -653,7 +655,10 @@ func TestTask_Execute_cache(t *testing.T) {
 			},
 		}
 
-		cachePath, err := task.taskInputCachePath(ctx)
+		inputs, err := task.taskInputs(ctx, nil)
+		g.NoError(err)
+
+		cachePath, err := task.taskInputCachePath(ctx, inputs)
 		g.NoError(err)
 
 		err = os.MkdirAll(filepath.Dir(cachePath), 0o700)
@@ -716,7 +721,7 @@ func TestTask_run_sub_tasks(t *testing.T) {
 	}
 
 	r := &Run{
-		Tasks: []Task{taskSuccess},
+		Tasks: []*Task{&taskSuccess},
 	}
 
 	task := Task{}
@@ -724,7 +729,7 @@ func TestTask_run_sub_tasks(t *testing.T) {
 	err := task.run(Context{Logger: ui.Noop()}, r, stateRunning)
 	g.NoError(err)
 
-	r.Tasks = append(r.Tasks, taskFailure)
+	r.Tasks = append(r.Tasks, &taskFailure)
 
 	err = task.run(Context{Logger: ui.Noop()}, r, stateRunning)
 	g.Should(be.ErrorEqual(err, "exit status 1"))
@@ -870,3 +875,342 @@ func TestTask_run_finally_ui_error(t *testing.T) {
 
 	g.Should(be.Equal(got.String(), want.String()))
 }
+
+func TestTask_Execute_sub_tasks_run_once(t *testing.T) {
+	g := ghost.New(t)
+
+	cfgText := []byte(`
+tasks:
+  setup:
+    args:
+      target: {}
+    run: echo setup ${target}
+  lint:
+    run:
+      - task: {name: setup, args: [a]}
+  test:
+    run:
+      - task: {name: setup, args: [a]}
+      - task: {name: setup, args: [b]}
+  all:
+    run:
+      - task: [lint, test]
+      - task:
+          name: setup
+          args: [a]
+`)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  cfgText,
+		TaskName: "all",
+	})
+	g.NoError(err)
+
+	var stdout bytes.Buffer
+	logger := ui.New(ui.Config{
+		Stdout: &stdout,
+		Stderr: io.Discard,
+	})
+
+	err = cfg.Tasks["all"].Execute(Context{Logger: logger})
+	g.NoError(err)
+
+	g.Should(be.Equal(stdout.String(), "setup a\nsetup b\n"))
+}
+
+func TestTask_Execute_sub_tasks_run_once_error(t *testing.T) {
+	g := ghost.New(t)
+
+	failure := &Task{
+		Name: "failure",
+		RunList: marshal.Slice[*Run]{
+			{Command: marshal.Slice[*Command]{{Exec: "exit 1"}}},
+		},
+	}
+
+	ctx := Context{
+		Logger:     ui.Noop(),
+		executions: new(executions),
+	}
+
+	err := failure.Execute(ctx)
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+
+	// The error is returned again without re-running the task.
+	failure.RunList = nil
+	err = failure.Execute(ctx)
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+}
+
+func TestTask_Execute_dry_run(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfgText := []byte(`
+options:
+  version:
+    default:
+      command: echo 1.0.0
+tasks:
+  setup:
+    args:
+      target: {}
+    run:
+      - set-environment: {DRY_RUN_TEST: value}
+      - exec: touch ${target}
+        quiet: true
+  mytask:
+    options:
+      skip: {type: bool}
+    run:
+      - when: {os: fake}
+        command: touch skipped
+      - when: {equal: {skip: false}}
+        task: {name: setup, args: [setup.txt]}
+      - touch ${version}
+`)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText:  cfgText,
+		TaskName: "mytask",
+		DryRun:   true,
+	})
+	g.NoError(err)
+
+	var stderr bytes.Buffer
+	logger := ui.New(ui.Config{
+		Stdout:    io.Discard,
+		Stderr:    &stderr,
+		Verbosity: ui.LevelVerbose,
+	})
+
+	t.Setenv("DRY_RUN_TEST", "")
+
+	t.Cleanup(func() { timeNow = time.Now })
+	timeNow = func() time.Time { return time.Time{} }
+
+	err = cfg.Tasks["mytask"].Execute(Context{Logger: logger, DryRun: true})
+	g.NoError(err)
+
+	entries, err := os.ReadDir(".")
+	g.NoError(err)
+	g.Should(be.SliceLen(entries, 0))
+	g.Should(be.Equal(os.Getenv("DRY_RUN_TEST"), ""))
+
+	g.Should(be.Equal(stderr.String(), `Task Started: mytask
+Task Values: mytask
+ => skip=false
+Skipping Command: touch skipped
+ => current OS (`+runtime.GOOS+`) not listed in [fake]
+Condition Met: setup
+Task Started: setup
+Task Values: setup
+ => target=setup.txt
+Setting Environment
+ => set DRY_RUN_TEST=value
+mytask > setup $ touch setup.txt
+Task Completed: setup (0s)
+mytask $ touch $(echo 1.0.0)
+Task Completed: mytask (0s)
+`))
+}
+
+func TestTask_Execute_dry_run_cache(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	err := os.WriteFile("input.txt", []byte("data"), 0o600)
+	g.NoError(err)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgPath: "tusk.yml",
+		CfgText: []byte(`
+tasks:
+  build:
+    source: input.txt
+    target: output.txt
+    run: touch output.txt
+`),
+		TaskName: "build",
+	})
+	g.NoError(err)
+
+	err = cfg.Tasks["build"].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop()})
+	g.NoError(err)
+
+	before := dirState(t, cacheHome)
+	g.Should(be.True(len(before) > 0))
+
+	t.Cleanup(func() { timeNow = time.Now })
+	timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+
+	err = cfg.Tasks["build"].Execute(Context{CfgPath: "tusk.yml", Logger: ui.Noop(), DryRun: true})
+	g.NoError(err)
+
+	g.Should(be.DeepEqual(dirState(t, cacheHome), before))
+}
+
+func TestTask_Execute_dry_run_cache_values(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{
+			name:    "static",
+			version: `{default: 1.0.0}`,
+			want:    "all targets up to date",
+		},
+		{
+			name:    "command",
+			version: `{default: {command: echo 1.0.0}}`,
+			want:    `Cannot tell whether task "build" is up to date`,
+		},
+		{
+			name:    "dependency",
+			version: `{default: "${release}"}`,
+			want:    `Cannot tell whether task "build" is up to date`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			xtesting.UseTempDir(t)
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+			err := os.WriteFile("input.txt", []byte("data"), 0o600)
+			g.NoError(err)
+
+			cfgText := []byte(`
+options:
+  release:
+    default:
+      - when: {command: "true"}
+        value: 1.0.0
+  version: ` + tt.version + `
+tasks:
+  build:
+    source: input.txt
+    target: output.txt
+    run: echo ${version} > output.txt
+`)
+
+			for _, dryRun := range []bool{false, true} {
+				cfg, err := ParseComplete(&ParseConfig{
+					CfgPath:  "tusk.yml",
+					CfgText:  cfgText,
+					TaskName: "build",
+					DryRun:   dryRun,
+				})
+				g.NoError(err)
+
+				var stderr bytes.Buffer
+				logger := ui.New(ui.Config{
+					Stdout:    io.Discard,
+					Stderr:    &stderr,
+					Verbosity: ui.LevelVerbose,
+				})
+
+				err = cfg.Tasks["build"].Execute(Context{
+					CfgPath: "tusk.yml",
+					Logger:  logger,
+					DryRun:  dryRun,
+				})
+				g.NoError(err)
+
+				if dryRun {
+					g.Should(be.StringContaining(stderr.String(), tt.want))
+				}
+			}
+		})
+	}
+}
+
+// dirState returns the contents and modification time of every file in a
+// directory.
+func dirState(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	state := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		state[path] = fmt.Sprintf("%s %s", info.ModTime(), data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return state
+}
+
+func TestTask_Execute_capture(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("RELEASE_VERSION", "")
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  release:
+    run:
+      - command:
+          exec: echo v1.2.3
+          capture: version
+      - when:
+          equal: {version: v1.2.3}
+        command: echo ${version} > version.txt
+      - set-environment: {RELEASE_VERSION: "${version}"}
+      - echo $RELEASE_VERSION > env.txt
+`, "release")
+	g.NoError(err)
+
+	got, err := os.ReadFile("version.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "v1.2.3\n"))
+
+	got, err = os.ReadFile("env.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "v1.2.3\n"))
+}
+
+func TestTask_Execute_capture_parallel(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+tasks:
+  both:
+    run:
+      - parallel: 2
+        command:
+          - {exec: echo one, capture: first}
+          - {exec: echo two, capture: second}
+      - echo ${first} ${second} > out.txt
+`, "both")
+	g.NoError(err)
+
+	got, err := os.ReadFile("out.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "one two\n"))
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// defaultGracePeriod is how long a command has to exit after it is asked to
+// terminate before it is killed.
+const defaultGracePeriod = 10 * time.Second
+
+// timeoutError is the cause of a context cancelled by a timeout.
+type timeoutError struct {
+	kind    string
+	name    string
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s %q timed out after %s", e.kind, e.name, e.timeout)
+}
+
+// IsTimeout checks if an error was because of a timeout.
+func IsTimeout(err error) bool {
+	var timeoutErr *timeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// withTimeout returns a context that is cancelled once the timeout expires.
+// A timeout of zero never expires.
+func withTimeout(
+	ctx Context, timeout time.Duration, kind, name string,
+) (Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	cctx, cancel := context.WithTimeoutCause(
+		ctx.Context(),
+		timeout,
+		&timeoutError{kind: kind, name: name, timeout: timeout},
+	)
+
+	return ctx.WithContext(cctx), cancel
+}
+
+// gracePeriod returns the grace period of the command, or of the innermost
+// task that sets one.
+func gracePeriod(ctx Context, c *Command) time.Duration {
+	if c.GracePeriod > 0 {
+		return c.GracePeriod
+	}
+
+	for i := len(ctx.taskStack) - 1; i >= 0; i-- {
+		if p := ctx.taskStack[i].GracePeriod; p > 0 {
+			return p
+		}
+	}
+
+	return defaultGracePeriod
+}
+
+// terminateOnCancel asks the command to exit once its context is done, and
+// kills it if it has not exited after the grace period.
+//
+// Commands cancelled by a signal receive the same signal, while all others are
+// sent SIGTERM. Once the grace period expires, every process in the command's
+// process group is killed, including any that outlived the command itself.
+// Commands left in the terminal's process group have no group of their own, so
+// only the command itself is signalled and killed.
+func terminateOnCancel(ctx Context, cmd *exec.Cmd, grace time.Duration) {
+	cmd.Cancel = func() error {
+		time.AfterFunc(grace, func() {
+			signalProcess(cmd, os.Kill) //nolint:errcheck
+		})
+		return signalProcess(cmd, cancelSignal(ctx))
+	}
+	cmd.WaitDelay = grace
+}
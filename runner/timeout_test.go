@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestCommand_exec_timeout(t *testing.T) {
+	g := ghost.New(t)
+
+	command := Command{
+		Exec:    "sleep 5",
+		Print:   "sleep 5",
+		Timeout: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := command.exec(Context{Logger: ui.Noop()})
+	g.Should(be.ErrorEqual(err, `command "sleep 5" timed out after 50ms: signal: terminated`))
+	g.Should(be.True(time.Since(start) < 5*time.Second))
+}
+
+func TestCommand_exec_timeout_grace_period(t *testing.T) {
+	g := ghost.New(t)
+
+	command := Command{
+		Exec:        "trap '' TERM; sleep 5 & wait",
+		Print:       "trap",
+		Timeout:     50 * time.Millisecond,
+		GracePeriod: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := command.exec(Context{Logger: ui.Noop()})
+	g.Should(be.ErrorContaining(err, `command "trap" timed out after 50ms`))
+	g.Should(be.True(time.Since(start) < 5*time.Second))
+}
+
+func TestGracePeriod(t *testing.T) {
+	g := ghost.New(t)
+
+	outer := &Task{GracePeriod: 2 * time.Second}
+	inner := &Task{}
+
+	g.Should(be.Equal(gracePeriod(Context{}, &Command{}), defaultGracePeriod))
+
+	ctx := Context{}.WithTask(outer).WithTask(inner)
+	g.Should(be.Equal(gracePeriod(ctx, &Command{}), 2*time.Second))
+	g.Should(be.Equal(gracePeriod(ctx, &Command{GracePeriod: time.Second}), time.Second))
+}
+
+func TestTask_Execute_timeout(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	task := Task{
+		Name:    "slow",
+		Timeout: 50 * time.Millisecond,
+		RunList: marshal.Slice[*Run]{
+			{Command: marshal.Slice[*Command]{{Exec: "sleep 5", Print: "sleep 5"}}},
+			{Command: marshal.Slice[*Command]{{Exec: "touch skipped", Print: "touch skipped"}}},
+		},
+		Finally: marshal.Slice[*Run]{
+			{Command: marshal.Slice[*Command]{{Exec: "touch finally", Print: "touch finally"}}},
+		},
+	}
+
+	err := task.Execute(Context{Logger: ui.Noop()})
+	g.Should(be.ErrorEqual(err, `task "slow" timed out after 50ms: signal: terminated`))
+
+	_, err = os.Stat("finally")
+	g.NoError(err)
+
+	_, err = os.Stat("skipped")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestTask_Execute_timeout_grace_period(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes cannot be signalled on windows")
+	}
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		t.Skip("commands share the process group of a terminal")
+	}
+
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	// The child ignores SIGTERM, and would outlive its parent without being
+	// killed along with the rest of the process group.
+	task := Task{
+		Name:        "stubborn",
+		Timeout:     50 * time.Millisecond,
+		GracePeriod: 100 * time.Millisecond,
+		RunList: marshal.Slice[*Run]{{Command: marshal.Slice[*Command]{{
+			Exec:  `trap "" TERM; (sleep 1 && touch survived) & wait`,
+			Print: "stubborn",
+		}}}},
+	}
+
+	err := task.Execute(Context{Logger: ui.Noop()})
+	g.Should(be.ErrorContaining(err, `task "stubborn" timed out after 50ms`))
+
+	time.Sleep(1500 * time.Millisecond)
+
+	_, err = os.Stat("survived")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestParseComplete_timeout(t *testing.T) {
+	g := ghost.New(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+tasks:
+  mytask:
+    timeout: 1m
+    grace-period: 2s
+    options:
+      foo: {default: bar}
+    run:
+      command:
+        exec: echo ${foo}
+        timeout: 5s
+`),
+		TaskName: "mytask",
+	})
+	g.NoError(err)
+
+	task := cfg.Tasks["mytask"]
+	g.Should(be.Equal(task.Timeout, time.Minute))
+	g.Should(be.Equal(task.GracePeriod, 2*time.Second))
+
+	g.NoError(task.vars.resolve(Context{}, task.RunList[0].Command))
+
+	r, err := task.RunList[0].interpolate(task.Vars)
+	g.NoError(err)
+
+	command := r.Command[0]
+	g.Should(be.Equal(command.Exec, "echo bar"))
+	g.Should(be.Equal(command.Timeout, 5*time.Second))
+}
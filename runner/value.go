@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -13,24 +14,68 @@ type Value struct {
 	When    WhenList
 	Command string
 	Value   string
+
+	// Cache caches the output of the command across invocations.
+	Cache *ValueCache `yaml:",omitempty"`
 }
 
 // commandValueOrDefault validates a content definition, then gets the value.
 func (v *Value) commandValueOrDefault(ctx Context) (string, error) {
 	if v.Command != "" {
-		cmd := newCmd(ctx, v.Command)
+		if ctx.DryRun {
+			return fmt.Sprintf("$(%s)", v.Command), nil
+		}
 
-		out, err := cmd.Output()
-		if err != nil {
-			return "", err
+		if v.Cache != nil {
+			return v.cachedCommandValue(ctx)
 		}
 
-		return strings.TrimSpace(string(out)), nil
+		return v.commandValue(ctx)
 	}
 
 	return v.Value, nil
 }
 
+// commandValue runs the command and returns its trimmed output.
+func (v *Value) commandValue(ctx Context) (string, error) {
+	cmd := newCmd(ctx, v.Command)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// cachedCommandValue returns the cached output of the command, running the
+// command only if there is no valid cached value.
+func (v *Value) cachedCommandValue(ctx Context) (string, error) {
+	cachePath, err := v.Cache.path(ctx, v.Command)
+	if err != nil {
+		return "", fmt.Errorf("checking cache: %w", err)
+	}
+
+	value, ok, err := v.Cache.load(cachePath)
+	if err != nil {
+		return "", fmt.Errorf("checking cache: %w", err)
+	}
+	if ok {
+		return value, nil
+	}
+
+	value, err = v.commandValue(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := v.Cache.store(cachePath, value); err != nil {
+		return "", fmt.Errorf("caching value: %w", err)
+	}
+
+	return value, nil
+}
+
 // UnmarshalYAML allows plain strings to represent a full struct. The value of
 // the string is used as the Default field.
 func (v *Value) UnmarshalYAML(unmarshal func(any) error) error {
@@ -53,6 +98,14 @@ func (v *Value) UnmarshalYAML(unmarshal func(any) error) error {
 				)
 			}
 
+			if valueItem.Cache != nil {
+				if valueItem.Command == "" {
+					return errors.New("cache can only be defined for a command")
+				}
+
+				return valueItem.Cache.isValid()
+			}
+
 			return nil
 		},
 	}
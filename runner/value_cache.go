@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// ValueCache configures how the output of a value's command is cached across
+// invocations.
+type ValueCache struct {
+	// TTL is how long a cached value is used before the command is run again.
+	// Cached values do not expire if unset.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+
+	// KeyFiles are files whose contents are part of the cache key, so that
+	// changing them runs the command again.
+	KeyFiles marshal.Slice[string] `yaml:"key-files,omitempty"`
+}
+
+// isValid checks whether a value cache definition is valid.
+func (c *ValueCache) isValid() error {
+	if c.TTL < 0 {
+		return fmt.Errorf("cache ttl must not be negative, got %s", c.TTL)
+	}
+
+	return nil
+}
+
+// load returns the cached output of a command, if there is a cached value that
+// has not expired.
+func (c *ValueCache) load(cachePath string) (string, bool, error) {
+	info, err := os.Stat(cachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if c.TTL != 0 && timeNow().Sub(info.ModTime()) >= c.TTL {
+		return "", false, nil
+	}
+
+	value, err := os.ReadFile(cachePath)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(value), true, nil
+}
+
+// store caches the output of a command.
+func (c *ValueCache) store(cachePath string, value string) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(cachePath, []byte(value), 0o600)
+}
+
+// path returns a unique file path based on a command and its inputs.
+func (c *ValueCache) path(ctx Context, command string) (string, error) {
+	cacheDir, err := valueCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	keyChecksum, err := dirChecksum("key", ctx.Dir(), c.KeyFiles, nil, nil)
+	var pnfe *patternNotFoundError
+	switch {
+	case errors.As(err, &pnfe):
+		keyChecksum = ""
+	case err != nil:
+		return "", err
+	}
+
+	dir, err := filepath.Abs(ctx.Dir())
+	if err != nil {
+		return "", err
+	}
+
+	interpreter := strings.Join(ctx.Interpreter, " ")
+
+	h := sha256.New()
+	for _, s := range []string{dir, interpreter, command, keyChecksum} {
+		// Null bytes separate the fields, so their boundaries are unambiguous.
+		if _, err := io.WriteString(h, s+"\x00"); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(cacheDir, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// valuesCacheDir is the name of the directory in the cache that holds cached
+// option values, rather than the task cache of a project.
+const valuesCacheDir = "values"
+
+// valueCacheDir returns the directory for cached option values.
+func valueCacheDir() (string, error) {
+	cacheDir, err := tuskCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, valuesCacheDir), nil
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/marshal"
+)
+
+func TestValue_UnmarshalYAML_cache(t *testing.T) {
+	g := ghost.New(t)
+
+	var v Value
+	err := yaml.UnmarshalStrict([]byte(`
+command: git rev-parse HEAD
+cache:
+  ttl: 10m
+  key-files: [.git/HEAD]
+`), &v)
+	g.NoError(err)
+
+	g.Should(be.DeepEqual(v, Value{
+		Command: "git rev-parse HEAD",
+		Cache: &ValueCache{
+			TTL:      10 * time.Minute,
+			KeyFiles: marshal.Slice[string]{".git/HEAD"},
+		},
+	}))
+}
+
+func TestValue_UnmarshalYAML_cache_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "without command",
+			input:   `{value: foo, cache: {ttl: 1m}}`,
+			wantErr: "cache can only be defined for a command",
+		},
+		{
+			name:    "negative ttl",
+			input:   `{command: echo foo, cache: {ttl: -1m}}`,
+			wantErr: "cache ttl must not be negative, got -1m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			var v Value
+			err := yaml.UnmarshalStrict([]byte(tt.input), &v)
+			g.Should(be.ErrorEqual(err, tt.wantErr))
+		})
+	}
+}
+
+func TestValue_commandValueOrDefault_cache(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xtesting.UseTempDir(t)
+
+	now := time.Now()
+	t.Cleanup(func() { timeNow = time.Now })
+	timeNow = func() time.Time { return now }
+
+	err := os.WriteFile("key.txt", []byte("one"), 0o600)
+	g.NoError(err)
+
+	v := Value{
+		Command: "echo run >> runs.txt && wc -l < runs.txt",
+		Cache: &ValueCache{
+			TTL:      time.Hour,
+			KeyFiles: marshal.Slice[string]{"key.txt"},
+		},
+	}
+
+	got, err := v.commandValueOrDefault(Context{})
+	g.NoError(err)
+	g.Should(be.Equal(got, "1"))
+
+	got, err = v.commandValueOrDefault(Context{})
+	g.NoError(err)
+	g.Should(be.Equal(got, "1"))
+
+	err = os.WriteFile("key.txt", []byte("two"), 0o600)
+	g.NoError(err)
+
+	got, err = v.commandValueOrDefault(Context{})
+	g.NoError(err)
+	g.Should(be.Equal(got, "2"))
+
+	timeNow = func() time.Time { return now.Add(2 * time.Hour) }
+
+	got, err = v.commandValueOrDefault(Context{})
+	g.NoError(err)
+	g.Should(be.Equal(got, "3"))
+}
+
+func TestValue_commandValueOrDefault_cache_error(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xtesting.UseTempDir(t)
+
+	v := Value{
+		Command: "echo run >> runs.txt && exit 1",
+		Cache:   &ValueCache{},
+	}
+
+	_, err := v.commandValueOrDefault(Context{})
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+
+	_, err = v.commandValueOrDefault(Context{})
+	g.Should(be.ErrorEqual(err, "exit status 1"))
+
+	got, err := os.ReadFile("runs.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "run\nrun\n"))
+}
+
+func TestTask_Execute_cached_option(t *testing.T) {
+	g := ghost.New(t)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xtesting.UseTempDir(t)
+
+	cfgText := `
+options:
+  identity:
+    default:
+      command: echo run >> runs.txt && echo ${user}
+      cache: {ttl: 10m}
+  user:
+    default: alice
+tasks:
+  whoami:
+    run: echo ${identity} >> out.txt
+`
+
+	for range 2 {
+		err := executeTask(t, cfgText, "whoami")
+		g.NoError(err)
+	}
+
+	got, err := os.ReadFile("runs.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "run\n"))
+
+	got, err = os.ReadFile("out.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "alice\nalice\n"))
+}
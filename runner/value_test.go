@@ -23,6 +23,16 @@ func TestValue_UnmarshalYAML(t *testing.T) {
 	g.Should(be.Equal(v1.Value, "example"))
 }
 
+func TestValue_commandValueOrDefault_dry_run(t *testing.T) {
+	g := ghost.New(t)
+
+	v := Value{Command: "exit 1"}
+
+	got, err := v.commandValueOrDefault(Context{DryRun: true})
+	g.NoError(err)
+	g.Should(be.Equal(got, "$(exit 1)"))
+}
+
 func TestValue_UnmarshalYAML_value_and_command(t *testing.T) {
 	g := ghost.New(t)
 
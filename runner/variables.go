@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// variables holds the values that a task can interpolate: its args, its
+// options, and the shared options it uses.
+//
+// Options are evaluated the first time their value is needed, so the commands
+// in option defaults only run when the option is actually used. The value is
+// cached by the option, so shared options are only evaluated once.
+type variables struct {
+	passed  map[string]string
+	values  map[string]string
+	options map[string]*Option
+
+	// global holds the shared options, which cannot interpolate the args and
+	// options of the task.
+	global *variables
+
+	// mu guards the evaluation state of options, since shared options are used
+	// by every task, including tasks run in parallel. It is only held to check
+	// or update that state, and never while an option is being evaluated.
+	mu *sync.Mutex
+}
+
+// newVariables returns the variables for a set of options. Values passed for
+// the options are validated immediately, even though the options themselves
+// are evaluated later.
+func newVariables(
+	mu *sync.Mutex,
+	passed map[string]string,
+	options Options,
+	global *variables,
+) (*variables, error) {
+	v := &variables{
+		passed:  passed,
+		values:  make(map[string]string),
+		options: make(map[string]*Option, len(options)),
+		global:  global,
+		mu:      mu,
+	}
+
+	for _, o := range options {
+		if valuePassed, ok := passed[o.Name]; ok {
+			o.Passed = valuePassed
+		}
+
+		if err := o.validateSpecified(); err != nil {
+			return nil, err
+		}
+
+		v.options[o.Name] = o
+	}
+
+	return v, nil
+}
+
+// resolve evaluates the options that any of the items may interpolate.
+func (v *variables) resolve(ctx Context, items ...any) error {
+	if v == nil {
+		return nil
+	}
+
+	var names []string
+	for _, item := range items {
+		references, err := getReferences(item)
+		if err != nil {
+			return err
+		}
+		names = append(names, references...)
+
+		if getter, ok := item.(dependencyGetter); ok {
+			names = append(names, getter.Dependencies()...)
+		}
+	}
+
+	return v.resolveNames(ctx, names...)
+}
+
+// resolveNames evaluates the options with the given names.
+func (v *variables) resolveNames(ctx Context, names ...string) error {
+	if v == nil {
+		return nil
+	}
+
+	for _, name := range names {
+		if err := v.resolveName(ctx, name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveName evaluates the option with the given name. Options that are
+// already being evaluated are skipped, so that an option's references to its
+// own name are left to the shared options.
+func (v *variables) resolveName(ctx Context, name string, evaluating []*Option) error {
+	if _, ok := v.values[name]; ok {
+		return nil
+	}
+
+	if o, ok := v.options[name]; ok && !slices.Contains(evaluating, o) {
+		return v.resolveOption(ctx, o, evaluating)
+	}
+
+	if v.global == nil {
+		return nil
+	}
+
+	if err := v.global.resolveName(ctx, name, evaluating); err != nil {
+		return err
+	}
+
+	if value, ok := v.global.values[name]; ok {
+		v.values[name] = value
+	}
+
+	return nil
+}
+
+// resolveOption adds the value of an option, evaluating it unless it has
+// already been evaluated for another task.
+func (v *variables) resolveOption(ctx Context, o *Option, evaluating []*Option) error {
+	if !v.claim(o) {
+		v.values[o.Name] = o.interpolatedValue(o.cacheValue)
+		return nil
+	}
+
+	value, err := v.evaluate(ctx, o, append(slices.Clip(evaluating), o))
+	v.release(o, value, err)
+	if err != nil {
+		return err
+	}
+
+	v.values[o.Name] = o.interpolatedValue(value)
+	return nil
+}
+
+// evaluate returns the value of an option once the values it depends on have
+// been resolved.
+func (v *variables) evaluate(ctx Context, o *Option, evaluating []*Option) (string, error) {
+	dependencies, err := getDependencies(o)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range dependencies {
+		if err := v.resolveName(ctx, name, evaluating); err != nil {
+			return "", err
+		}
+	}
+
+	if err := marshal.Interpolate(o, v.values); err != nil {
+		return "", err
+	}
+
+	for _, name := range dependencies {
+		if v.isPlaceholder(name) {
+			o.isPlaceholder = true
+		}
+	}
+
+	if valuePassed, ok := v.passed[o.Name]; ok {
+		o.Passed = valuePassed
+	}
+
+	return o.getValue(ctx, v.values)
+}
+
+// isPlaceholder reports whether the value of an option depends on a command
+// that was not run during a dry run.
+func (v *variables) isPlaceholder(name string) bool {
+	if v == nil {
+		return false
+	}
+
+	if o, ok := v.options[name]; ok && o.isPlaceholder {
+		return true
+	}
+
+	return v.global.isPlaceholder(name)
+}
+
+// hasPlaceholders reports whether any of the values that have been resolved
+// depend on a command that was not run during a dry run.
+func (v *variables) hasPlaceholders() bool {
+	if v == nil {
+		return false
+	}
+
+	for name := range v.values {
+		if v.isPlaceholder(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// claim reports whether an option still needs to be evaluated, waiting for
+// any other task that is evaluating it to finish first. An option that is
+// claimed must be released once it has been evaluated.
+func (v *variables) claim(o *Option) bool {
+	for {
+		v.mu.Lock()
+		if o.isCacheSet {
+			v.mu.Unlock()
+			return false
+		}
+
+		done := o.evaluating
+		if done == nil {
+			o.evaluating = make(chan struct{})
+			v.mu.Unlock()
+			return true
+		}
+		v.mu.Unlock()
+
+		<-done
+	}
+}
+
+// release caches the value of a claimed option if it was evaluated, and lets
+// any tasks waiting for it continue.
+func (v *variables) release(o *Option, value string, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err == nil {
+		o.cache(value)
+	}
+
+	close(o.evaluating)
+	o.evaluating = nil
+}
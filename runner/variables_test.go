@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+func TestTask_Execute_lazy_options(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+options:
+  version:
+    default:
+      command: echo evaluated >> version.txt && echo v1
+  unused:
+    default:
+      command: touch unused.txt
+tasks:
+  release:
+    options:
+      publish:
+        type: bool
+    run:
+      - when: {equal: {publish: true}}
+        command: echo ${version}
+      - echo done
+`),
+		TaskName: "release",
+	})
+	g.NoError(err)
+
+	_, err = os.Stat("version.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+
+	err = cfg.Tasks["release"].Execute(Context{Logger: ui.Noop()})
+	g.NoError(err)
+
+	_, err = os.Stat("version.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+
+	_, err = os.Stat("unused.txt")
+	g.Should(be.ErrorIs(err, os.ErrNotExist))
+}
+
+func TestTask_Execute_lazy_options_evaluated_once(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := executeTask(t, `
+options:
+  version:
+    default:
+      command: echo evaluated >> version.txt && echo v1
+tasks:
+  lint:
+    run: echo ${version}
+  release:
+    run:
+      - parallel: 2
+        task: [lint, test]
+      - echo ${version}
+  test:
+    run: echo ${version}
+`, "release")
+	g.NoError(err)
+
+	got, err := os.ReadFile("version.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "evaluated\n"))
+}
+
+func TestParseComplete_lazy_options_validated(t *testing.T) {
+	g := ghost.New(t)
+
+	_, err := ParseComplete(&ParseConfig{
+		CfgText: []byte(`
+tasks:
+  release:
+    options:
+      channel:
+        values: [stable, beta]
+    run:
+      - when: {os: none}
+        command: echo ${channel}
+`),
+		Flags:    map[string]string{"channel": "nightly"},
+		TaskName: "release",
+	})
+	g.Should(be.ErrorEqual(err, `value "nightly" for option "channel" must be one of [stable, beta]`))
+}
+
+func TestTask_Execute_lazy_options_parallel(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	// Each option waits for the other to start, so they can only be evaluated
+	// at the same time.
+	err := executeTask(t, `
+options:
+  first:
+    default:
+      command: >-
+        touch first.txt &&
+        for i in $(seq 100); do [ -f second.txt ] && echo 1 && exit 0; sleep 0.05; done;
+        exit 1
+  second:
+    default:
+      command: >-
+        touch second.txt &&
+        for i in $(seq 100); do [ -f first.txt ] && echo 2 && exit 0; sleep 0.05; done;
+        exit 1
+tasks:
+  a:
+    run: echo ${first} >> values.txt
+  b:
+    run: echo ${second} >> values.txt
+  release:
+    run:
+      parallel: 2
+      task: [a, b]
+`, "release")
+	g.NoError(err)
+
+	got, err := os.ReadFile("values.txt")
+	g.NoError(err)
+	g.Should(be.SliceContaining([]string{"1\n2\n", "2\n1\n"}, string(got)))
+}
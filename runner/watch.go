@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"time"
+)
+
+var (
+	// watchPollInterval is how often watched files are checked for changes.
+	watchPollInterval = 500 * time.Millisecond
+
+	// watchDebounce is how long watched files must stay unchanged after a
+	// change before the task is run again.
+	watchDebounce = 200 * time.Millisecond
+)
+
+// errFilesChanged is the cause of a run that was cancelled so that it can be
+// started again.
+var errFilesChanged = errors.New("watched files changed")
+
+// fileStamp is the state of a watched file. A file whose stamp has not changed
+// is assumed to have the same content.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// fileSnapshot is the state of each watched file.
+type fileSnapshot map[string]fileStamp
+
+// ExecuteOnChange runs the task, then runs it again each time one of its
+// watched files changes, until the context is cancelled.
+//
+// The task watches its watch patterns, or its source patterns if it has none.
+// A run that is still going when files change is cancelled before the next one
+// starts.
+func (t *Task) ExecuteOnChange(ctx Context) error {
+	patterns := t.watchPatterns()
+	if len(patterns) == 0 {
+		return fmt.Errorf("task %q has no source or watch patterns to watch", t.Name)
+	}
+
+	snapshot, err := t.watchSnapshot(ctx, patterns)
+	if err != nil {
+		return err
+	}
+
+	run := t.startWatchRun(ctx)
+	for {
+		next, err := t.waitForChange(ctx, patterns, snapshot)
+		run.stop()
+		if err != nil {
+			return err
+		}
+
+		snapshot = next
+		ctx.Logger.Info(fmt.Sprintf("Files changed, running task %q again", t.Name))
+		run = t.startWatchRun(ctx)
+	}
+}
+
+// watchPatterns returns the patterns of the files that the task watches.
+func (t *Task) watchPatterns() []string {
+	if len(t.Watch) > 0 {
+		return t.Watch
+	}
+
+	return t.Source
+}
+
+// waitForChange polls the watched files until they differ from the snapshot,
+// then waits for them to settle. It returns the settled snapshot, or the cause
+// of the context being cancelled.
+func (t *Task) waitForChange(
+	ctx Context, patterns []string, snapshot fileSnapshot,
+) (fileSnapshot, error) {
+	interval := watchPollInterval
+	for {
+		select {
+		case <-ctx.Context().Done():
+			return nil, context.Cause(ctx.Context())
+		case <-time.After(interval):
+		}
+
+		next, err := t.watchSnapshot(ctx, patterns)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !maps.Equal(snapshot, next):
+			snapshot, interval = next, watchDebounce
+		case interval == watchDebounce:
+			return snapshot, nil
+		}
+	}
+}
+
+// watchSnapshot returns the state of each file that matches the patterns.
+// Patterns that do not match any files are allowed, since files may be
+// created later.
+func (t *Task) watchSnapshot(ctx Context, patterns []string) (fileSnapshot, error) {
+	var ignore *gitignore
+	if t.Gitignore {
+		ignore = newGitignore(os.DirFS(ctx.Dir()))
+	}
+
+	include, exclude := splitPatterns(patterns)
+	snapshot := make(fileSnapshot)
+	for _, pattern := range include {
+		single := []string{pattern}
+		for _, e := range exclude {
+			single = append(single, "!"+e)
+		}
+
+		err := snapshotPatterns(snapshot, os.DirFS(ctx.Dir()), single, ignore)
+		var pnfe *patternNotFoundError
+		if err != nil && !errors.As(err, &pnfe) {
+			return nil, err
+		}
+	}
+
+	return snapshot, nil
+}
+
+// snapshotPatterns adds the state of each file that matches the patterns to a
+// snapshot. Files removed while walking are left out.
+func snapshotPatterns(
+	snapshot fileSnapshot, fsys fs.FS, patterns []string, ignore *gitignore,
+) error {
+	entries := make(chan entry)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(entries)
+		errc <- walkEntries(context.Background(), entries, "watch", fsys, patterns, ignore)
+	}()
+
+	for e := range entries {
+		info, err := e.d.Info()
+		if err != nil {
+			continue
+		}
+
+		snapshot[e.path] = fileStamp{
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			mode:    info.Mode(),
+		}
+	}
+
+	return <-errc
+}
+
+// watchRun is a run of a watched task.
+type watchRun struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// startWatchRun runs the task in the background. Failures are reported rather
+// than returned, so that the task can be run again once files change.
+func (t *Task) startWatchRun(ctx Context) *watchRun {
+	cctx, cancel := context.WithCancelCause(ctx.Context())
+	run := &watchRun{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(run.done)
+
+		err := t.Execute(ctx.WithContext(cctx))
+		if err != nil && cctx.Err() == nil {
+			reportWatchFailure(ctx, err)
+		}
+	}()
+
+	return run
+}
+
+// stop cancels the run and waits for it to finish.
+func (r *watchRun) stop() {
+	r.cancel(errFilesChanged)
+	<-r.done
+}
+
+// reportWatchFailure reports why a run of a watched task failed.
+func reportWatchFailure(ctx Context, err error) {
+	var failuresErr *FailuresError
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &failuresErr):
+		ctx.Logger.PrintFailures(failuresErr.Failures)
+	case errors.As(err, &exitErr):
+		// The failed command has already been reported.
+	default:
+		ctx.Logger.Error(err)
+	}
+}
@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+
+	"github.com/rliebz/tusk/internal/xtesting"
+	"github.com/rliebz/tusk/ui"
+)
+
+// errStopWatching stops a watched task in tests.
+var errStopWatching = errors.New("stop watching")
+
+// startWatching parses a task and executes it in watch mode. The returned
+// function stops watching and returns the result.
+func startWatching(t *testing.T, cfgText, taskName string) func() error {
+	t.Helper()
+	g := ghost.New(t)
+
+	pollInterval, debounce := watchPollInterval, watchDebounce
+	t.Cleanup(func() { watchPollInterval, watchDebounce = pollInterval, debounce })
+	watchPollInterval, watchDebounce = 10*time.Millisecond, 20*time.Millisecond
+
+	cfg, err := ParseComplete(&ParseConfig{
+		CfgPath:  "tusk.yml",
+		CfgText:  []byte(cfgText),
+		TaskName: taskName,
+	})
+	g.NoError(err)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- cfg.Tasks[taskName].ExecuteOnChange(
+			Context{CfgPath: "tusk.yml", Logger: ui.Noop()}.WithContext(ctx),
+		)
+	}()
+
+	return func() error {
+		cancel(errStopWatching)
+		return <-errc
+	}
+}
+
+// writeWatchedFile writes a file that is being watched.
+func writeWatchedFile(t *testing.T, name, content string) {
+	t.Helper()
+	g := ghost.New(t)
+
+	err := os.WriteFile(name, []byte(content), 0o600)
+	g.NoError(err)
+}
+
+func TestTask_ExecuteOnChange(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := os.Mkdir("src", 0o700)
+	g.NoError(err)
+	writeWatchedFile(t, "src/input.txt", "first")
+
+	stop := startWatching(t, `
+tasks:
+  build:
+    source: src/*
+    target: out.txt
+    run: cp src/input.txt out.txt && touch "ran-$(cat src/input.txt)"
+`, "build")
+
+	waitForFile(t, "ran-first")
+	writeWatchedFile(t, "src/input.txt", "second")
+	waitForFile(t, "ran-second")
+
+	writeWatchedFile(t, "src/added.txt", "added")
+	writeWatchedFile(t, "src/input.txt", "third")
+	waitForFile(t, "ran-third")
+
+	err = stop()
+	g.Should(be.ErrorIs(err, errStopWatching))
+}
+
+func TestTask_ExecuteOnChange_cancels_run(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	writeWatchedFile(t, "input.txt", "first")
+
+	stop := startWatching(t, `
+tasks:
+  serve:
+    watch: input.txt
+    grace-period: 100ms
+    run: |
+      name=$(cat input.txt)
+      touch "started-$name"
+      sleep 10
+      touch "finished-$name"
+`, "serve")
+
+	waitForFile(t, "started-first")
+	writeWatchedFile(t, "input.txt", "second")
+	waitForFile(t, "started-second")
+
+	err := stop()
+	g.Should(be.ErrorIs(err, errStopWatching))
+
+	for _, name := range []string{"finished-first", "finished-second"} {
+		_, err = os.Stat(name)
+		g.Should(be.ErrorIs(err, os.ErrNotExist))
+	}
+}
+
+func TestTask_ExecuteOnChange_exclude(t *testing.T) {
+	g := ghost.New(t)
+
+	xtesting.UseTempDir(t)
+
+	err := os.Mkdir("src", 0o700)
+	g.NoError(err)
+	writeWatchedFile(t, "src/input.txt", "first")
+	writeWatchedFile(t, "src/generated.txt", "first")
+
+	stop := startWatching(t, `
+tasks:
+  build:
+    watch: [src/*, "!src/generated.txt"]
+    run: echo ran >> runs.txt
+`, "build")
+
+	waitForFile(t, "runs.txt")
+	writeWatchedFile(t, "src/generated.txt", "second")
+	time.Sleep(100 * time.Millisecond)
+
+	err = stop()
+	g.Should(be.ErrorIs(err, errStopWatching))
+
+	got, err := os.ReadFile("runs.txt")
+	g.NoError(err)
+	g.Should(be.Equal(string(got), "ran\n"))
+}
+
+func TestTask_ExecuteOnChange_no_patterns(t *testing.T) {
+	g := ghost.New(t)
+
+	task := &Task{Name: "lint"}
+	err := task.ExecuteOnChange(Context{Logger: ui.Noop()})
+	g.Should(be.ErrorEqual(err, `task "lint" has no source or watch patterns to watch`))
+}
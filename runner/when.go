@@ -145,6 +145,11 @@ func (w *When) validateCommand(ctx Context) error {
 		return newUnspecifiedError("command")
 	}
 
+	// Commands are assumed to succeed, since they cannot be run.
+	if ctx.DryRun {
+		return nil
+	}
+
 	for _, command := range w.Command {
 		if err := testCommand(ctx, command); err == nil {
 			return nil
@@ -344,6 +349,21 @@ func (l *WhenList) Validate(ctx Context, vars map[string]string) error {
 	return nil
 }
 
+// hasCommand reports whether any when clause runs a command.
+func (l *WhenList) hasCommand() bool {
+	if l == nil {
+		return false
+	}
+
+	for _, w := range *l {
+		if len(w.Command) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Dependencies returns a list of options that are required explicitly.
 // This does not include interpolations.
 func (l *WhenList) Dependencies() []string {
@@ -409,6 +409,19 @@ func TestWhen_Validate(t *testing.T) {
 	}
 }
 
+func TestWhen_Validate_dry_run(t *testing.T) {
+	g := ghost.New(t)
+
+	// Commands are assumed to succeed without being run.
+	w := createWhen(withWhenCommandFailure)
+	err := w.Validate(Context{DryRun: true}, nil)
+	g.NoError(err)
+
+	// Other clauses are evaluated as normal.
+	err = whenFalse.Validate(Context{DryRun: true}, nil)
+	g.Should(be.Error(err))
+}
+
 func TestNormalizeOS(t *testing.T) {
 	tests := []struct {
 		input string
@@ -2,8 +2,10 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -11,12 +13,18 @@ const (
 	promptCharacter    = "$"
 
 	completedString      = "Completed"
+	conditionMetString   = "Condition Met"
 	environmentString    = "Setting Environment"
+	failuresString       = "Failures"
 	finallyString        = "Finally"
+	retryingString       = "Retrying"
 	startedString        = "Started"
 	skippedCommandString = "Skipping Command"
 	skippedTaskString    = "Skipping Task"
 	taskString           = "Task"
+	upToDateString       = "all targets up to date"
+	restoredString       = "targets restored from artifact cache"
+	valuesString         = "Values"
 
 	setEnvironmentString   = "set"
 	unsetEnvironmentString = "unset"
@@ -24,6 +32,14 @@ const (
 
 // PrintCommand prints the command to be executed.
 func (l Logger) PrintCommand(command string, namespaces ...string) {
+	if l.event(Event{
+		Type:    EventCommandStarted,
+		Command: command,
+		Tasks:   namespaces,
+	}) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -39,6 +55,15 @@ func (l Logger) PrintCommand(command string, namespaces ...string) {
 
 // PrintCommandWithParenthetical prints a command with additional information.
 func (l Logger) PrintCommandWithParenthetical(command, parenthetical string, namespaces ...string) {
+	if l.event(Event{
+		Type:    EventCommandStarted,
+		Command: command,
+		Detail:  parenthetical,
+		Tasks:   namespaces,
+	}) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -61,6 +86,13 @@ func (l Logger) PrintCommandWithParenthetical(command, parenthetical string, nam
 
 // PrintEnvironment prints when environment variables are set.
 func (l Logger) PrintEnvironment(variables map[string]*string) {
+	if len(variables) != 0 && l.event(Event{
+		Type:        EventEnvironment,
+		Environment: variables,
+	}) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -114,6 +146,14 @@ func (l Logger) PrintEnvironment(variables map[string]*string) {
 
 // PrintCommandSkipped prints the command skipped and the reason.
 func (l Logger) PrintCommandSkipped(command, reason string) {
+	if l.event(Event{
+		Type:    EventCommandSkipped,
+		Command: command,
+		Reason:  reason,
+	}) {
+		return
+	}
+
 	if l.Level() < LevelVerbose {
 		return
 	}
@@ -137,6 +177,14 @@ func (l Logger) PrintCommandSkipped(command, reason string) {
 
 // PrintTaskSkipped prints the task skipped and the reason.
 func (l Logger) PrintTaskSkipped(task, reason string) {
+	if l.event(Event{
+		Type:   EventTaskSkipped,
+		Task:   task,
+		Reason: reason,
+	}) {
+		return
+	}
+
 	if l.Level() < LevelVerbose {
 		return
 	}
@@ -158,8 +206,97 @@ func (l Logger) PrintTaskSkipped(task, reason string) {
 	)
 }
 
+// PrintConditionMet prints a command or task whose when clause has passed.
+func (l Logger) PrintConditionMet(item string) {
+	if l.event(Event{
+		Type: EventConditionMet,
+		Name: item,
+	}) {
+		return
+	}
+
+	if l.Level() < LevelVerbose {
+		return
+	}
+
+	fmt.Fprintf(
+		l.Stderr(),
+		logFormat,
+		tag(conditionMetString, cyan),
+		bold(item),
+	)
+}
+
+// secretMask is printed in place of the values of secret options.
+const secretMask = "********"
+
+// PrintTaskValues prints the values of a task's args and options, in order.
+// The values of secrets are masked.
+func (l Logger) PrintTaskValues(
+	taskName string,
+	names []string,
+	values map[string]string,
+	secrets []string,
+) {
+	value := func(name string) string {
+		if slices.Contains(secrets, name) {
+			return secretMask
+		}
+		return values[name]
+	}
+
+	if l.sink != nil {
+		taskValues := make(map[string]string, len(names))
+		for _, name := range names {
+			taskValues[name] = value(name)
+		}
+		l.event(Event{
+			Type:   EventTaskValues,
+			Task:   taskName,
+			Values: taskValues,
+		})
+		return
+	}
+
+	if l.Level() < LevelVerbose {
+		return
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	f := blue
+
+	s := fmt.Sprintf("%s %s", taskString, valuesString)
+
+	fmt.Fprintf(
+		l.Stderr(),
+		logFormat,
+		tag(s, f),
+		bold(taskName),
+	)
+
+	for _, name := range names {
+		fmt.Fprintf(
+			l.Stderr(),
+			"%s%s=%s\n",
+			f(outputPrefix),
+			bold(name),
+			value(name),
+		)
+	}
+}
+
 // PrintTask prints when a task has begun.
 func (l Logger) PrintTask(taskName string) {
+	if l.event(Event{
+		Type: EventTaskStarted,
+		Task: taskName,
+	}) {
+		return
+	}
+
 	if l.level <= LevelNormal {
 		return
 	}
@@ -176,6 +313,13 @@ func (l Logger) PrintTask(taskName string) {
 
 // PrintTaskFinally prints when a task's finally clause has begun.
 func (l Logger) PrintTaskFinally(taskName string) {
+	if l.event(Event{
+		Type: EventTaskFinally,
+		Task: taskName,
+	}) {
+		return
+	}
+
 	if l.level <= LevelNormal {
 		return
 	}
@@ -190,8 +334,23 @@ func (l Logger) PrintTaskFinally(taskName string) {
 	)
 }
 
-// PrintTaskCompleted prints when a task has completed.
-func (l Logger) PrintTaskCompleted(taskName string) {
+// PrintTaskCompleted prints when a task has completed, and how long it took.
+func (l Logger) PrintTaskCompleted(taskName string, elapsed time.Duration) {
+	l.timings.record(timing{
+		kind:    taskKind,
+		name:    taskName,
+		elapsed: elapsed,
+	})
+
+	seconds := elapsed.Seconds()
+	if l.event(Event{
+		Type:    EventTaskCompleted,
+		Task:    taskName,
+		Elapsed: &seconds,
+	}) {
+		return
+	}
+
 	if l.level <= LevelNormal {
 		return
 	}
@@ -200,14 +359,156 @@ func (l Logger) PrintTaskCompleted(taskName string) {
 
 	fmt.Fprintf(
 		l.Stderr(),
-		logFormat,
+		"%s %s (%s)\n",
 		tag(s, blue),
 		bold(taskName),
+		formatDuration(elapsed),
 	)
 }
 
+// PrintTaskUpToDate prints when a task is skipped because its targets are up
+// to date.
+func (l Logger) PrintTaskUpToDate(taskName string) {
+	l.timings.record(timing{
+		kind:   taskKind,
+		name:   taskName,
+		cached: true,
+	})
+
+	l.PrintTaskSkipped(taskName, upToDateString)
+}
+
+// PrintTaskRestored prints when a task is skipped because its targets were
+// restored from the artifact cache.
+func (l Logger) PrintTaskRestored(taskName string) {
+	l.timings.record(timing{
+		kind:   taskKind,
+		name:   taskName,
+		cached: true,
+	})
+
+	l.PrintTaskSkipped(taskName, restoredString)
+}
+
+// PrintCommandCompleted records when a command has finished running.
+//
+// Completed commands are only reported as events and timings, and print no
+// text.
+func (l Logger) PrintCommandCompleted(
+	command string, exitCode int, elapsed time.Duration, namespaces ...string,
+) {
+	l.timings.record(timing{
+		kind:    commandKind,
+		name:    commandName(command, namespaces),
+		elapsed: elapsed,
+	})
+
+	seconds := elapsed.Seconds()
+	l.event(Event{
+		Type:     EventCommandCompleted,
+		Command:  command,
+		Tasks:    namespaces,
+		ExitCode: &exitCode,
+		Elapsed:  &seconds,
+	})
+}
+
+// PrintRetry prints when a failed command or task is about to be attempted
+// again.
+func (l Logger) PrintRetry(item string, attempt, attempts int, delay time.Duration, err error) {
+	seconds := delay.Seconds()
+	if l.event(Event{
+		Type:     EventRetry,
+		Name:     item,
+		Attempt:  attempt,
+		Attempts: attempts,
+		Delay:    &seconds,
+		Message:  err.Error(),
+	}) {
+		return
+	}
+
+	if l.level <= LevelQuiet {
+		return
+	}
+
+	f := yellow
+
+	fmt.Fprintf(
+		l.Stderr(),
+		logFormat,
+		tag(retryingString, f),
+		bold(item),
+	)
+
+	fmt.Fprintf(
+		l.Stderr(),
+		"%sattempt %d of %d in %s after error: %s\n",
+		f(outputPrefix),
+		attempt,
+		attempts,
+		formatDuration(delay),
+		err,
+	)
+}
+
+// Failure is a failed command or task to include in a summary.
+type Failure struct {
+	Command  string
+	Tasks    []string
+	ExitCode int
+	Message  string
+}
+
+// PrintFailures prints a summary of every failure that occurred during a run.
+func (l Logger) PrintFailures(failures []Failure) {
+	if l.sink != nil {
+		for _, f := range failures {
+			exitCode := f.ExitCode
+			l.event(Event{
+				Type:     EventFailure,
+				Command:  f.Command,
+				Tasks:    f.Tasks,
+				ExitCode: &exitCode,
+				Message:  f.Message,
+			})
+		}
+		return
+	}
+
+	if l.level <= LevelSilent || len(failures) == 0 {
+		return
+	}
+
+	f := red
+
+	fmt.Fprintf(l.Stderr(), logFormat, tag(failuresString, f), bold(len(failures)))
+
+	for _, failure := range failures {
+		if failure.Command == "" {
+			fmt.Fprintf(l.Stderr(), "%s%s\n", f(outputPrefix), failure.Message)
+			continue
+		}
+
+		fmt.Fprintf(
+			l.Stderr(),
+			"%s%s (%s)\n",
+			f(outputPrefix),
+			commandName(failure.Command, failure.Tasks),
+			failure.Message,
+		)
+	}
+}
+
 // PrintCommandError prints an error from a running command.
 func (l Logger) PrintCommandError(err error) {
+	if l.event(Event{
+		Type:    EventError,
+		Message: err.Error(),
+	}) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 var commandTests = []printTestCase{
@@ -81,6 +82,43 @@ var commandTests = []printTestCase{
 			"oops",
 		),
 	},
+	{
+		`PrintConditionMet("echo hello")`,
+		withStderr,
+		func(l *Logger) { l.PrintConditionMet("echo hello") },
+		LevelNormal,
+		LevelVerbose,
+		"Condition Met: echo hello\n",
+	},
+	{
+		`PrintTaskValues("foo", ...)`,
+		withStderr,
+		func(l *Logger) {
+			l.PrintTaskValues("foo", []string{"b", "a"}, map[string]string{"a": "1", "b": "2"}, nil)
+		},
+		LevelNormal,
+		LevelVerbose,
+		fmt.Sprintf("Task Values: foo\n%sb=2\n%sa=1\n", outputPrefix, outputPrefix),
+	},
+	{
+		`PrintTaskValues("foo", ...) with secrets`,
+		withStderr,
+		func(l *Logger) {
+			values := map[string]string{"a": "1", "b": "2"}
+			l.PrintTaskValues("foo", []string{"a", "b"}, values, []string{"b"})
+		},
+		LevelNormal,
+		LevelVerbose,
+		fmt.Sprintf("Task Values: foo\n%sa=1\n%sb=********\n", outputPrefix, outputPrefix),
+	},
+	{
+		`PrintTaskValues("foo", nil, nil)`,
+		withStderr,
+		func(l *Logger) { l.PrintTaskValues("foo", nil, nil, nil) },
+		LevelNormal,
+		LevelVerbose,
+		"",
+	},
 	{
 		`PrintTask("foo")`,
 		withStderr,
@@ -98,12 +136,72 @@ var commandTests = []printTestCase{
 		"Task Finally: foo\n",
 	},
 	{
-		`PrintTaskCompleted("foo")`,
+		`PrintTaskCompleted("foo", 1500*time.Millisecond)`,
+		withStderr,
+		func(l *Logger) { l.PrintTaskCompleted("foo", 1500*time.Millisecond) },
+		LevelNormal,
+		LevelVerbose,
+		"Task Completed: foo (1.5s)\n",
+	},
+	{
+		`PrintTaskUpToDate("foo")`,
+		withStderr,
+		func(l *Logger) { l.PrintTaskUpToDate("foo") },
+		LevelNormal,
+		LevelVerbose,
+		fmt.Sprintf(
+			"%s %s\n%s%s\n",
+			tag(skippedTaskString, yellow),
+			"foo",
+			outputPrefix,
+			"all targets up to date",
+		),
+	},
+	{
+		`PrintTaskRestored("foo")`,
 		withStderr,
-		func(l *Logger) { l.PrintTaskCompleted("foo") },
+		func(l *Logger) { l.PrintTaskRestored("foo") },
 		LevelNormal,
 		LevelVerbose,
-		"Task Completed: foo\n",
+		fmt.Sprintf(
+			"%s %s\n%s%s\n",
+			tag(skippedTaskString, yellow),
+			"foo",
+			outputPrefix,
+			"targets restored from artifact cache",
+		),
+	},
+	{
+		`PrintRetry("flaky", 2, 3, time.Second, errors.New("oops"))`,
+		withStderr,
+		func(l *Logger) { l.PrintRetry("flaky", 2, 3, time.Second, errors.New("oops")) },
+		LevelQuiet,
+		LevelNormal,
+		fmt.Sprintf(
+			"%s %s\n%sattempt 2 of 3 in 1s after error: oops\n",
+			tag(retryingString, yellow),
+			"flaky",
+			outputPrefix,
+		),
+	},
+	{
+		`PrintFailures(...)`,
+		withStderr,
+		func(l *Logger) {
+			l.PrintFailures([]Failure{
+				{Command: "exit 1", Tasks: []string{"foo", "bar"}, ExitCode: 1, Message: "exit status 1"},
+				{ExitCode: -1, Message: "oops"},
+			})
+		},
+		LevelSilent,
+		LevelQuiet,
+		fmt.Sprintf(
+			"%s %s\n%sfoo > bar $ exit 1 (exit status 1)\n%soops\n",
+			tag(failuresString, red),
+			"2",
+			outputPrefix,
+			outputPrefix,
+		),
 	},
 	{
 		`PrintCommandError(errors.New("oops"))`,
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Format is the format of the logger's output.
+type Format string
+
+const (
+	// FormatText prints human-readable text.
+	FormatText Format = "text"
+	// FormatJSON prints one JSON object per event.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q (one of: text, json)", name)
+	}
+}
+
+// EventType describes what happened during an event.
+type EventType string
+
+// The types of events that can occur.
+const (
+	EventCommandStarted   EventType = "command_started"
+	EventCommandCompleted EventType = "command_completed"
+	EventCommandSkipped   EventType = "command_skipped"
+	EventConditionMet     EventType = "condition_met"
+	EventEnvironment      EventType = "environment"
+	EventError            EventType = "error"
+	EventFailure          EventType = "failure"
+	EventLog              EventType = "log"
+	EventOutput           EventType = "output"
+	EventRetry            EventType = "retry"
+	EventTaskStarted      EventType = "task_started"
+	EventTaskValues       EventType = "task_values"
+	EventTaskFinally      EventType = "task_finally"
+	EventTaskCompleted    EventType = "task_completed"
+	EventTaskSkipped      EventType = "task_skipped"
+)
+
+// Event is something that happened during execution.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	Task        string             `json:"task,omitempty"`
+	Tasks       []string           `json:"tasks,omitempty"`
+	Command     string             `json:"command,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	Detail      string             `json:"detail,omitempty"`
+	Reason      string             `json:"reason,omitempty"`
+	ExitCode    *int               `json:"exit_code,omitempty"`
+	Elapsed     *float64           `json:"elapsed,omitempty"`
+	Attempt     int                `json:"attempt,omitempty"`
+	Attempts    int                `json:"attempts,omitempty"`
+	Delay       *float64           `json:"delay,omitempty"`
+	Environment map[string]*string `json:"environment,omitempty"`
+	Values      map[string]string  `json:"values,omitempty"`
+	Level       string             `json:"level,omitempty"`
+	Message     string             `json:"message,omitempty"`
+	Labels      []string           `json:"labels,omitempty"`
+	Stream      string             `json:"stream,omitempty"`
+	Text        string             `json:"text,omitempty"`
+}
+
+// Sink receives events as they occur.
+type Sink interface {
+	Event(Event)
+}
+
+// jsonSink writes each event as a line of JSON.
+type jsonSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func newJSONSink(w io.Writer) *jsonSink {
+	return &jsonSink{w: w, now: time.Now}
+}
+
+// Event writes the event to the underlying writer.
+func (s *jsonSink) Event(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.Time = s.now()
+	json.NewEncoder(s.w).Encode(e) //nolint:errcheck,errchkjson
+}
+
+// SetFormat sets the format of the logger's output.
+//
+// With FormatJSON, every message is written to standard error as an event,
+// including the output of any commands. All events are written regardless of
+// verbosity, unless the logger is silent.
+func (l *Logger) SetFormat(f Format) {
+	if f != FormatJSON || l.sink != nil {
+		return
+	}
+
+	sink := newJSONSink(l.Stderr())
+	l.sink = sink
+	l.stdout = &outputWriter{sink: sink, stream: "stdout"}
+	l.stderr = &outputWriter{sink: sink, stream: "stderr"}
+}
+
+// Format returns the format of the logger's output.
+func (l *Logger) Format() Format {
+	if l.sink != nil {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// event sends an event to the sink, if the logger has one. It reports whether
+// the event was handled.
+func (l Logger) event(e Event) bool {
+	if l.sink == nil {
+		return false
+	}
+
+	if l.level > LevelSilent {
+		e.Labels = l.labels
+		l.sink.Event(e)
+	}
+
+	return true
+}
+
+// outputWriter is a line-buffered writer that converts each line into an
+// output event.
+type outputWriter struct {
+	mu     sync.Mutex
+	sink   Sink
+	stream string
+	labels []string
+	buf    []byte
+}
+
+// withLabel returns a new writer for the same stream with an added label.
+func (w *outputWriter) withLabel(label string) *outputWriter {
+	return &outputWriter{
+		sink:   w.sink,
+		stream: w.stream,
+		labels: append(slices.Clip(w.labels), label),
+	}
+}
+
+// Write buffers the input and sends an event for each complete line.
+func (w *outputWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+
+		w.send(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush sends any trailing partial line.
+func (w *outputWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) != 0 {
+		w.send(string(w.buf))
+		w.buf = nil
+	}
+
+	return nil
+}
+
+func (w *outputWriter) send(line string) {
+	w.sink.Event(Event{
+		Type:   EventOutput,
+		Labels: w.labels,
+		Stream: w.stream,
+		Text:   line,
+	})
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestParseFormat(t *testing.T) {
+	g := ghost.New(t)
+
+	got, err := ParseFormat("")
+	g.NoError(err)
+	g.Should(be.Equal(got, FormatText))
+
+	got, err = ParseFormat("json")
+	g.NoError(err)
+	g.Should(be.Equal(got, FormatJSON))
+
+	_, err = ParseFormat("xml")
+	g.Should(be.ErrorEqual(err, `invalid output format "xml" (one of: text, json)`))
+}
+
+func newJSONLogger(level Level) (*Logger, *bytes.Buffer) {
+	var stderr bytes.Buffer
+	logger := New(Config{Stderr: &stderr, Verbosity: level})
+	logger.SetFormat(FormatJSON)
+	logger.sink.(*jsonSink).now = func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	return logger, &stderr
+}
+
+func TestLogger_SetFormat_json(t *testing.T) {
+	const timestamp = `"time":"2020-01-02T03:04:05Z"`
+
+	tests := []struct {
+		name  string
+		print func(l *Logger)
+		want  string
+	}{
+		{
+			name:  "task started",
+			print: func(l *Logger) { l.PrintTask("foo") },
+			want:  `{` + timestamp + `,"type":"task_started","task":"foo"}`,
+		},
+		{
+			name:  "task completed",
+			print: func(l *Logger) { l.PrintTaskCompleted("foo", 2*time.Second) },
+			want:  `{` + timestamp + `,"type":"task_completed","task":"foo","elapsed":2}`,
+		},
+		{
+			name: "task values",
+			print: func(l *Logger) {
+				values := map[string]string{"a": "1", "b": "2"}
+				l.PrintTaskValues("foo", []string{"a", "b"}, values, []string{"b"})
+			},
+			want: `{` + timestamp + `,"type":"task_values","task":"foo",` +
+				`"values":{"a":"1","b":"********"}}`,
+		},
+		{
+			name:  "task skipped",
+			print: func(l *Logger) { l.PrintTaskSkipped("foo", "oops") },
+			want:  `{` + timestamp + `,"type":"task_skipped","task":"foo","reason":"oops"}`,
+		},
+		{
+			name:  "command started",
+			print: func(l *Logger) { l.PrintCommand("echo hello", "foo", "bar") },
+			want: `{` + timestamp +
+				`,"type":"command_started","tasks":["foo","bar"],"command":"echo hello"}`,
+		},
+		{
+			name: "command completed",
+			print: func(l *Logger) {
+				l.PrintCommandCompleted("echo hello", 2, 1500*time.Millisecond, "foo")
+			},
+			want: `{` + timestamp +
+				`,"type":"command_completed","tasks":["foo"],"command":"echo hello",` +
+				`"exit_code":2,"elapsed":1.5}`,
+		},
+		{
+			name: "environment",
+			print: func(l *Logger) {
+				a := "one"
+				l.PrintEnvironment(map[string]*string{"A": &a, "B": nil})
+			},
+			want: `{` + timestamp + `,"type":"environment","environment":{"A":"one","B":null}}`,
+		},
+		{
+			name: "retry",
+			print: func(l *Logger) {
+				l.PrintRetry("flaky", 2, 3, 500*time.Millisecond, errors.New("oops"))
+			},
+			want: `{` + timestamp + `,"type":"retry","name":"flaky","attempt":2,"attempts":3,` +
+				`"delay":0.5,"message":"oops"}`,
+		},
+		{
+			name: "failures",
+			print: func(l *Logger) {
+				l.PrintFailures([]Failure{{Command: "exit 1", ExitCode: 1, Message: "exit status 1"}})
+			},
+			want: `{` + timestamp + `,"type":"failure","command":"exit 1","exit_code":1,` +
+				`"message":"exit status 1"}`,
+		},
+		{
+			name:  "command error",
+			print: func(l *Logger) { l.PrintCommandError(errors.New("oops")) },
+			want:  `{` + timestamp + `,"type":"error","message":"oops"}`,
+		},
+		{
+			name:  "debug",
+			print: func(l *Logger) { l.Debug("foo", "bar") },
+			want:  `{` + timestamp + `,"type":"log","level":"debug","message":"foo\nbar"}`,
+		},
+		{
+			name:  "output",
+			print: func(l *Logger) { fmt.Fprintln(l.Stdout(), "hello") },
+			want:  `{` + timestamp + `,"type":"output","stream":"stdout","text":"hello"}`,
+		},
+		{
+			name: "prefixed output",
+			print: func(l *Logger) {
+				prefixed := l.WithPrefix("foo")
+				fmt.Fprint(prefixed.Stderr(), "hello")
+				prefixed.Flush()
+			},
+			want: `{` + timestamp + `,"type":"output","labels":["foo"],"stream":"stderr","text":"hello"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ghost.New(t)
+
+			logger, stderr := newJSONLogger(LevelQuiet)
+			tt.print(logger)
+
+			g.Should(be.Equal(stderr.String(), tt.want+"\n"))
+		})
+	}
+}
+
+func TestLogger_SetFormat_json_silent(t *testing.T) {
+	g := ghost.New(t)
+
+	logger, stderr := newJSONLogger(LevelSilent)
+	logger.PrintTask("foo")
+	logger.Error("oops")
+
+	g.Should(be.Equal(stderr.String(), ""))
+}
+
+func TestLogger_SetFormat_text(t *testing.T) {
+	g := ghost.New(t)
+
+	var stderr bytes.Buffer
+	logger := New(Config{Stderr: &stderr, Verbosity: LevelVerbose})
+	logger.SetFormat(FormatText)
+	logger.PrintCommandCompleted("echo hello", 0, time.Second)
+
+	g.Should(be.Equal(logger.Format(), FormatText))
+	g.Should(be.Equal(stderr.String(), ""))
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
 )
 
 const (
@@ -25,6 +26,24 @@ type Logger struct {
 	stdout, stderr io.Writer
 	level          Level
 
+	// sink receives events in place of text output, if set.
+	sink   Sink
+	labels []string
+
+	// groupOutput buffers the output of prefixed loggers until they are
+	// flushed, rather than printing each line as it is written.
+	groupOutput bool
+
+	// timings records the time spent on each task and command, if set.
+	timings *timings
+
+	// outputMu synchronizes the writes of prefixed loggers to stdout and
+	// stderr. It is shared by every logger with the same destinations.
+	outputMu *sync.Mutex
+
+	// log is the copy of the output added by WithLog, if any.
+	log *plainWriter
+
 	deprecations []string
 }
 
@@ -38,18 +57,20 @@ type Config struct {
 // New returns a new logger with the default settings.
 func New(cfg Config) *Logger {
 	return &Logger{
-		stdout: cfg.Stdout,
-		stderr: cfg.Stderr,
-		level:  cfg.Verbosity,
+		stdout:   cfg.Stdout,
+		stderr:   cfg.Stderr,
+		level:    cfg.Verbosity,
+		outputMu: new(sync.Mutex),
 	}
 }
 
 // Noop returns a logger that does not print anything.
 func Noop() *Logger {
 	return &Logger{
-		stdout: io.Discard,
-		stderr: io.Discard,
-		level:  LevelSilent,
+		stdout:   io.Discard,
+		stderr:   io.Discard,
+		level:    LevelSilent,
+		outputMu: new(sync.Mutex),
 	}
 }
 
@@ -84,6 +105,10 @@ func (l *Logger) Println(a ...any) {
 
 // Debug prints debug information.
 func (l *Logger) Debug(a ...any) {
+	if l.logEvent(debugString, a...) {
+		return
+	}
+
 	if l.level < LevelVerbose {
 		return
 	}
@@ -93,6 +118,10 @@ func (l *Logger) Debug(a ...any) {
 
 // Info prints normal application information.
 func (l *Logger) Info(a ...any) {
+	if l.logEvent(infoString, a...) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -102,6 +131,10 @@ func (l *Logger) Info(a ...any) {
 
 // Warn prints at the warning level.
 func (l *Logger) Warn(a ...any) {
+	if l.logEvent(warningString, a...) {
+		return
+	}
+
 	if l.level <= LevelQuiet {
 		return
 	}
@@ -111,6 +144,10 @@ func (l *Logger) Warn(a ...any) {
 
 // Error prints application errors.
 func (l *Logger) Error(a ...any) {
+	if l.logEvent(errorString, a...) {
+		return
+	}
+
 	if l.level <= LevelSilent {
 		return
 	}
@@ -120,7 +157,7 @@ func (l *Logger) Error(a ...any) {
 
 // Deprecate prints deprecation warnings no more than once.
 func (l *Logger) Deprecate(a ...any) {
-	if l.level <= LevelQuiet {
+	if l.level <= LevelQuiet && l.sink == nil {
 		return
 	}
 
@@ -132,6 +169,10 @@ func (l *Logger) Deprecate(a ...any) {
 		l.deprecations = append(l.deprecations, message)
 	}
 
+	if l.logEvent(deprecatedString, a...) {
+		return
+	}
+
 	l.logInStyle(deprecatedString, yellow, a...)
 	fmt.Fprintln(l.Stderr())
 }
@@ -145,3 +186,18 @@ func (l *Logger) logInStyle(title string, f formatter, a ...any) {
 
 	fmt.Fprintf(l.Stderr(), logFormat, tag(title, f), message)
 }
+
+// logEvent sends a log message as an event. It reports whether the event was
+// handled.
+func (l *Logger) logEvent(title string, a ...any) bool {
+	messages := make([]string, 0, len(a))
+	for _, message := range a {
+		messages = append(messages, fmt.Sprint(message))
+	}
+
+	return l.event(Event{
+		Type:    EventLog,
+		Level:   strings.ToLower(title),
+		Message: strings.Join(messages, "\n"),
+	})
+}
@@ -36,12 +36,13 @@ func (v Level) String() string {
 }
 
 var (
-	bold   = newFormatter(color.Bold)
-	blue   = newFormatter(color.FgBlue)
-	cyan   = newFormatter(color.FgCyan)
-	green  = newFormatter(color.FgGreen)
-	red    = newFormatter(color.FgRed)
-	yellow = newFormatter(color.FgYellow)
+	bold    = newFormatter(color.Bold)
+	blue    = newFormatter(color.FgBlue)
+	cyan    = newFormatter(color.FgCyan)
+	green   = newFormatter(color.FgGreen)
+	magenta = newFormatter(color.FgMagenta)
+	red     = newFormatter(color.FgRed)
+	yellow  = newFormatter(color.FgYellow)
 )
 
 type formatter func(a ...any) string
@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+const prefixSeparator = " | "
+
+// OutputMode is how the output of prefixed loggers, such as those of commands
+// running concurrently, is shown.
+type OutputMode string
+
+const (
+	// OutputPrefix prints each line as soon as it is complete, prefixed by a
+	// label.
+	OutputPrefix OutputMode = "prefix"
+	// OutputGroup buffers the output of each prefixed logger, and prints it in
+	// a single block once the logger is flushed.
+	OutputGroup OutputMode = "group"
+)
+
+// ParseOutputMode returns the output mode with the given name.
+func ParseOutputMode(name string) (OutputMode, error) {
+	switch OutputMode(name) {
+	case "", OutputPrefix:
+		return OutputPrefix, nil
+	case OutputGroup:
+		return OutputGroup, nil
+	default:
+		return "", fmt.Errorf("invalid output mode %q (one of: prefix, group)", name)
+	}
+}
+
+// SetOutputMode sets how the output of prefixed loggers is shown.
+func (l *Logger) SetOutputMode(m OutputMode) {
+	l.groupOutput = m == OutputGroup
+}
+
+// OutputMode returns how the output of prefixed loggers is shown.
+func (l *Logger) OutputMode() OutputMode {
+	if l.groupOutput {
+		return OutputGroup
+	}
+	return OutputPrefix
+}
+
+// WithPrefix returns a logger that prefixes each line of output with a label.
+// Each label is given its own color.
+//
+// Loggers created this way may be written to concurrently, and lines written
+// by each will not be interleaved. With OutputGroup, no output is written
+// until the logger is flushed. Flush must be called once the logger is no
+// longer in use to write any buffered output.
+func (l *Logger) WithPrefix(label string) *Logger {
+	prefixed := *l
+	if l.sink != nil {
+		prefixed.labels = append(slices.Clip(l.labels), label)
+		if w, ok := l.stdout.(*outputWriter); ok {
+			prefixed.stdout = w.withLabel(label)
+		}
+		if w, ok := l.stderr.(*outputWriter); ok {
+			prefixed.stderr = w.withLabel(label)
+		}
+		return &prefixed
+	}
+
+	mu := l.outputMu
+	if mu == nil {
+		mu = new(sync.Mutex)
+		prefixed.outputMu = mu
+	}
+
+	if l.groupOutput {
+		group := &outputGroup{outputMu: mu}
+		prefixed.stdout = group.writer(l.Stdout(), label)
+		prefixed.stderr = group.writer(l.Stderr(), label)
+		return &prefixed
+	}
+
+	prefixed.stdout = newPrefixWriter(l.Stdout(), label, mu)
+	prefixed.stderr = newPrefixWriter(l.Stderr(), label, mu)
+	return &prefixed
+}
+
+// labelColors are the colors given to labels, in order of first use.
+var labelColors = []formatter{cyan, yellow, green, magenta, blue}
+
+var (
+	labelsMu sync.Mutex
+	labels   = make(map[string]formatter)
+)
+
+// labelPrefix returns the prefix for a label, colored consistently so that the
+// output of each label is easy to tell apart.
+func labelPrefix(label string) string {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+
+	f, ok := labels[label]
+	if !ok {
+		f = labelColors[len(labels)%len(labelColors)]
+		labels[label] = f
+	}
+
+	return f(label) + prefixSeparator
+}
+
+// Flush writes any buffered output.
+func (l *Logger) Flush() {
+	for _, w := range []io.Writer{l.stdout, l.stderr} {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			f.Flush() //nolint:errcheck
+		}
+	}
+}
+
+// prefixWriter is a line-buffered writer that prefixes each line.
+type prefixWriter struct {
+	// mu is shared by all prefix writers with the same destination.
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// newPrefixWriter returns a writer that prefixes each line with a label. Writes
+// to the destination are synchronized by mu.
+//
+// Prefixing an existing prefix writer extends the prefix, so that all writes
+// to the destination are synchronized.
+func newPrefixWriter(w io.Writer, label string, mu *sync.Mutex) *prefixWriter {
+	prefix := labelPrefix(label)
+
+	if pw, ok := w.(*prefixWriter); ok {
+		return &prefixWriter{
+			mu:     pw.mu,
+			w:      pw.w,
+			prefix: append(bytes.Clone(pw.prefix), prefix...),
+		}
+	}
+
+	return &prefixWriter{
+		mu:     mu,
+		w:      w,
+		prefix: []byte(prefix),
+	}
+}
+
+// Write buffers the input and writes each complete line.
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return len(b), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes any trailing partial line.
+func (p *prefixWriter) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) == 0 {
+		return nil
+	}
+
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	_, err := p.w.Write(append(bytes.Clone(p.prefix), line...))
+	return err
+}
+
+// outputGroup buffers the output of a prefixed logger, so that it can be
+// written in a single block.
+type outputGroup struct {
+	// outputMu is shared by all groups with the same destinations, so that
+	// blocks of output are never interleaved.
+	outputMu *sync.Mutex
+
+	mu      sync.Mutex
+	writers []*groupWriter
+	lines   []groupLine
+}
+
+// groupLine is a prefixed line of output and its destination.
+type groupLine struct {
+	w    io.Writer
+	line []byte
+}
+
+// writer returns a writer whose lines are prefixed by a label and buffered in
+// the group until it is flushed.
+func (g *outputGroup) writer(w io.Writer, label string) *groupWriter {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gw := &groupWriter{group: g, w: w, prefix: []byte(labelPrefix(label))}
+	g.writers = append(g.writers, gw)
+	return gw
+}
+
+// groupWriter is a writer for a single destination within an output group.
+type groupWriter struct {
+	group  *outputGroup
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// Write buffers the input, adding each complete line to the group.
+func (gw *groupWriter) Write(b []byte) (int, error) {
+	gw.group.mu.Lock()
+	defer gw.group.mu.Unlock()
+
+	gw.buf = append(gw.buf, b...)
+	for {
+		i := bytes.IndexByte(gw.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+
+		gw.addLine(gw.buf[:i+1])
+		gw.buf = gw.buf[i+1:]
+	}
+}
+
+// Flush writes every line buffered in the group, including trailing partial
+// lines, in a single block.
+func (gw *groupWriter) Flush() error {
+	g := gw.group
+	g.mu.Lock()
+	for _, w := range g.writers {
+		if len(w.buf) > 0 {
+			w.addLine(append(w.buf, '\n'))
+			w.buf = nil
+		}
+	}
+	lines := g.lines
+	g.lines = nil
+	g.mu.Unlock()
+
+	g.outputMu.Lock()
+	defer g.outputMu.Unlock()
+
+	for _, l := range lines {
+		if _, err := l.w.Write(l.line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addLine adds a prefixed line to the group. The group must be locked.
+func (gw *groupWriter) addLine(line []byte) {
+	gw.group.lines = append(gw.group.lines, groupLine{
+		w:    gw.w,
+		line: append(bytes.Clone(gw.prefix), line...),
+	})
+}
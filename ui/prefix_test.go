@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestLogger_WithPrefix(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout, stderr bytes.Buffer
+	logger := New(Config{Stdout: &stdout, Stderr: &stderr})
+
+	prefixed := logger.WithPrefix("foo")
+	fmt.Fprint(prefixed.Stdout(), "one\ntw")
+	fmt.Fprint(prefixed.Stdout(), "o\nthree")
+	fmt.Fprintln(prefixed.Stderr(), "err")
+
+	g.Should(be.Equal(stdout.String(), "foo | one\nfoo | two\n"))
+	g.Should(be.Equal(stderr.String(), "foo | err\n"))
+
+	prefixed.Flush()
+	g.Should(be.Equal(stdout.String(), "foo | one\nfoo | two\nfoo | three\n"))
+}
+
+func TestLogger_WithPrefix_nested(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+
+	nested := logger.WithPrefix("foo").WithPrefix("bar")
+	fmt.Fprintln(nested.Stdout(), "hello")
+
+	g.Should(be.Equal(stdout.String(), "foo | bar | hello\n"))
+}
+
+func TestLogger_WithPrefix_concurrent(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+
+	var wg sync.WaitGroup
+	for _, label := range []string{"a", "b", "c"} {
+		prefixed := logger.WithPrefix(label)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				fmt.Fprint(prefixed.Stdout(), label)
+				fmt.Fprintln(prefixed.Stdout(), label)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, line := range bytes.Split(bytes.TrimSuffix(stdout.Bytes(), []byte("\n")), []byte("\n")) {
+		g.Should(be.SliceContaining([]string{"a | aa", "b | bb", "c | cc"}, string(line)))
+	}
+}
+
+// lineWriter is a writer that cannot be used as a map key.
+type lineWriter struct {
+	lines *[]string
+	_     []byte
+}
+
+func (w lineWriter) Write(b []byte) (int, error) {
+	*w.lines = append(*w.lines, string(b))
+	return len(b), nil
+}
+
+func TestLogger_WithPrefix_uncomparable_writer(t *testing.T) {
+	g := ghost.New(t)
+
+	var lines []string
+	logger := New(Config{Stdout: lineWriter{lines: &lines}})
+
+	fmt.Fprintln(logger.WithPrefix("foo").Stdout(), "hello")
+
+	g.Should(be.DeepEqual(lines, []string{"foo | hello\n"}))
+}
+
+func TestLogger_WithPrefix_log_concurrent(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout, log bytes.Buffer
+	logger := New(Config{Stdout: &stdout}).WithPrefix("task").WithLog(&log)
+
+	var wg sync.WaitGroup
+	for _, label := range []string{"a", "b"} {
+		prefixed := logger.WithPrefix(label)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				fmt.Fprintln(prefixed.Stdout(), label)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n") {
+		g.Should(be.SliceContaining([]string{"task | a | a", "task | b | b"}, line))
+	}
+	g.Should(be.Equal(strings.Count(log.String(), "\n"), 200))
+}
+
+func TestLogger_WithPrefix_colors(t *testing.T) {
+	g := ghost.New(t)
+
+	noColor := color.NoColor
+	t.Cleanup(func() { color.NoColor = noColor })
+	color.NoColor = false
+
+	first := labelPrefix("color-test-first")
+	second := labelPrefix("color-test-second")
+
+	g.Should(be.Not(be.Equal(
+		strings.TrimSuffix(first, "color-test-first"+prefixSeparator),
+		strings.TrimSuffix(second, "color-test-second"+prefixSeparator),
+	)))
+	g.Should(be.Equal(labelPrefix("color-test-first"), first))
+}
+
+func TestParseOutputMode(t *testing.T) {
+	g := ghost.New(t)
+
+	got, err := ParseOutputMode("")
+	g.NoError(err)
+	g.Should(be.Equal(got, OutputPrefix))
+
+	got, err = ParseOutputMode("group")
+	g.NoError(err)
+	g.Should(be.Equal(got, OutputGroup))
+
+	_, err = ParseOutputMode("tee")
+	g.Should(be.ErrorEqual(err, `invalid output mode "tee" (one of: prefix, group)`))
+}
+
+func TestLogger_WithPrefix_group(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout, stderr bytes.Buffer
+	logger := New(Config{Stdout: &stdout, Stderr: &stderr})
+	logger.SetOutputMode(OutputGroup)
+
+	prefixed := logger.WithPrefix("foo")
+	fmt.Fprint(prefixed.Stdout(), "one\ntw")
+	fmt.Fprintln(prefixed.Stderr(), "err")
+	fmt.Fprint(prefixed.Stdout(), "o\nthree")
+
+	g.Should(be.Equal(stdout.String(), ""))
+	g.Should(be.Equal(stderr.String(), ""))
+
+	prefixed.Flush()
+	g.Should(be.Equal(stdout.String(), "foo | one\nfoo | two\nfoo | three\n"))
+	g.Should(be.Equal(stderr.String(), "foo | err\n"))
+}
+
+func TestLogger_WithPrefix_group_nested(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+	logger.SetOutputMode(OutputGroup)
+
+	outer := logger.WithPrefix("foo")
+	fmt.Fprintln(outer.Stdout(), "before")
+
+	inner := outer.WithPrefix("bar")
+	fmt.Fprintln(inner.Stdout(), "hello")
+	inner.Flush()
+	g.Should(be.Equal(stdout.String(), ""))
+
+	outer.Flush()
+	g.Should(be.Equal(stdout.String(), "foo | before\nfoo | bar | hello\n"))
+}
+
+func TestLogger_WithPrefix_group_concurrent(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+	logger.SetOutputMode(OutputGroup)
+
+	var wg sync.WaitGroup
+	for _, label := range []string{"a", "b", "c"} {
+		prefixed := logger.WithPrefix(label)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer prefixed.Flush()
+			for range 100 {
+				fmt.Fprintln(prefixed.Stdout(), label)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each block of output is written in full before the next one.
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	g.Must(be.SliceLen(lines, 300))
+	for i := 0; i < len(lines); i += 100 {
+		for _, line := range lines[i : i+100] {
+			g.Should(be.Equal(line, lines[i]))
+		}
+	}
+}
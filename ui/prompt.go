@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const promptString = "> "
+
+// Prompt describes a value to ask the user for.
+type Prompt struct {
+	// Kind is the kind of value, such as "option" or "argument".
+	Kind string
+	Name string
+
+	// Usage explains what the value is for.
+	Usage string
+
+	// Values are the allowed values, which are offered as a numbered list.
+	Values []string
+
+	// Secret hides the value as it is typed.
+	Secret bool
+
+	// Validate checks a value before it is accepted. Invalid values are
+	// reported, and the value is asked for again.
+	Validate func(string) error
+}
+
+// Prompter asks the user for values.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// readSecret reads a line without echoing it. It is only set for
+	// terminals, and lines are read normally otherwise.
+	readSecret func() (string, error)
+}
+
+// NewPrompter returns a prompter that reads values from in and writes prompts
+// to out.
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{in: bufio.NewReader(in), out: out}
+
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.readSecret = func() (string, error) {
+			b, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(out)
+			return string(b), err
+		}
+	}
+
+	return p
+}
+
+// Prompt asks for a value until a valid one is entered. A value in the list
+// of allowed values may also be chosen by its number.
+func (p *Prompter) Prompt(q Prompt) (string, error) {
+	p.printPrompt(q)
+
+	for {
+		fmt.Fprint(p.out, promptString)
+
+		value, err := p.readLine(q.Secret)
+		if err != nil {
+			return "", fmt.Errorf("no value entered for %s %q: %w", q.Kind, q.Name, err)
+		}
+
+		value = choose(q.Values, value)
+		if err := validatePrompt(q, value); err != nil {
+			fmt.Fprintf(p.out, logFormat, tag(errorString, red), err)
+			continue
+		}
+
+		return value, nil
+	}
+}
+
+// printPrompt describes the value being asked for.
+func (p *Prompter) printPrompt(q Prompt) {
+	title := fmt.Sprintf("Enter a value for %s %q", q.Kind, q.Name)
+	if q.Usage != "" {
+		title += " (" + strings.TrimSpace(q.Usage) + ")"
+	}
+	fmt.Fprintln(p.out, bold(title))
+
+	for i, value := range q.Values {
+		fmt.Fprintf(p.out, "  %s %s\n", cyan(strconv.Itoa(i+1)+")"), value)
+	}
+}
+
+// readLine reads a line of input, without echoing it if it is secret.
+func (p *Prompter) readLine(secret bool) (string, error) {
+	if secret && p.readSecret != nil {
+		return p.readSecret()
+	}
+
+	line, err := p.in.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// choose returns the allowed value with the number entered, if the value
+// entered is not itself allowed.
+func choose(values []string, value string) string {
+	if slices.Contains(values, value) {
+		return value
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > len(values) {
+		return value
+	}
+
+	return values[n-1]
+}
+
+// validatePrompt checks that a value was entered and is valid.
+func validatePrompt(q Prompt, value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+
+	if q.Validate == nil {
+		return nil
+	}
+
+	return q.Validate(value)
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestPrompter_Prompt(t *testing.T) {
+	g := ghost.New(t)
+
+	var out bytes.Buffer
+	p := NewPrompter(strings.NewReader("hello\n"), &out)
+
+	value, err := p.Prompt(Prompt{Kind: "option", Name: "greeting", Usage: "What to say"})
+	g.NoError(err)
+
+	g.Should(be.Equal(value, "hello"))
+	g.Should(be.Equal(out.String(), "Enter a value for option \"greeting\" (What to say)\n> "))
+}
+
+func TestPrompter_Prompt_values(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "prod\n", want: "prod"},
+		{input: "2\n", want: "prod"},
+		{input: "3\n", want: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			g := ghost.New(t)
+
+			var out bytes.Buffer
+			p := NewPrompter(strings.NewReader(tt.input), &out)
+
+			value, err := p.Prompt(Prompt{
+				Kind:   "argument",
+				Name:   "env",
+				Values: []string{"dev", "prod", "3"},
+			})
+			g.NoError(err)
+
+			g.Should(be.Equal(value, tt.want))
+			g.Should(be.StringContaining(out.String(), "  1) dev\n  2) prod\n  3) 3\n"))
+		})
+	}
+}
+
+func TestPrompter_Prompt_invalid(t *testing.T) {
+	g := ghost.New(t)
+
+	var out bytes.Buffer
+	p := NewPrompter(strings.NewReader("\nfoo\n42"), &out)
+
+	value, err := p.Prompt(Prompt{
+		Kind: "option",
+		Name: "count",
+		Validate: func(s string) error {
+			if s == "foo" {
+				return fmt.Errorf("value %q is not a number", s)
+			}
+			return nil
+		},
+	})
+	g.NoError(err)
+
+	g.Should(be.Equal(value, "42"))
+	g.Should(be.Equal(out.String(), `Enter a value for option "count"
+> Error: a value is required
+> Error: value "foo" is not a number
+> `))
+}
+
+func TestPrompter_Prompt_eof(t *testing.T) {
+	g := ghost.New(t)
+
+	p := NewPrompter(strings.NewReader(""), io.Discard)
+
+	_, err := p.Prompt(Prompt{Kind: "option", Name: "foo"})
+	g.Should(be.ErrorEqual(err, `no value entered for option "foo": EOF`))
+}
+
+func TestPrompter_Prompt_secret(t *testing.T) {
+	g := ghost.New(t)
+
+	p := NewPrompter(strings.NewReader("visible\n"), io.Discard)
+	p.readSecret = func() (string, error) { return "hidden", nil }
+
+	value, err := p.Prompt(Prompt{Kind: "option", Name: "token", Secret: true})
+	g.NoError(err)
+	g.Should(be.Equal(value, "hidden"))
+}
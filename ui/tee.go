@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// WithLog returns a logger that also writes everything it prints to w,
+// including command output. Colors are removed from the copy written to w.
+//
+// With FormatJSON, each event is also written to w as a line of JSON.
+//
+// Colors are removed a line at a time, so FlushLog must be called once the
+// logger is no longer in use to write any trailing partial line.
+func (l *Logger) WithLog(w io.Writer) *Logger {
+	logged := *l
+	logged.log = &plainWriter{w: w}
+
+	if l.sink != nil {
+		sink := multiSink{l.sink, newJSONSink(logged.log)}
+		logged.sink = sink
+		if ow, ok := l.stdout.(*outputWriter); ok {
+			logged.stdout = &outputWriter{sink: sink, stream: ow.stream, labels: ow.labels}
+		}
+		if ow, ok := l.stderr.(*outputWriter); ok {
+			logged.stderr = &outputWriter{sink: sink, stream: ow.stream, labels: ow.labels}
+		}
+		return &logged
+	}
+
+	// The tee writers are new destinations, which may themselves write to
+	// synchronized writers, so they are synchronized separately.
+	logged.stdout = &teeWriter{w: l.Stdout(), log: logged.log}
+	logged.stderr = &teeWriter{w: l.Stderr(), log: logged.log}
+	logged.outputMu = new(sync.Mutex)
+	return &logged
+}
+
+// FlushLog writes any trailing partial line to the log added by WithLog.
+func (l *Logger) FlushLog() {
+	if l.log != nil {
+		l.log.Flush() //nolint:errcheck
+	}
+}
+
+// multiSink sends each event to every sink.
+type multiSink []Sink
+
+// Event sends the event to every sink.
+func (s multiSink) Event(e Event) {
+	for _, sink := range s {
+		sink.Event(e)
+	}
+}
+
+// teeWriter writes to its destination, and copies everything written to a log.
+type teeWriter struct {
+	w   io.Writer
+	log io.Writer
+}
+
+// Write writes to the destination, then to the log.
+func (t *teeWriter) Write(b []byte) (int, error) {
+	n, err := t.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+
+	if _, err := t.log.Write(b); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// Flush flushes the destination and the log, if they are buffered.
+func (t *teeWriter) Flush() error {
+	for _, w := range []io.Writer{t.w, t.log} {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ansiPattern matches the escape sequences used to color output.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// plainWriter is a line-buffered writer that removes colors from everything
+// written to it. Lines are buffered so that escape sequences split across
+// writes are still removed.
+type plainWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// Write buffers the input and writes every complete line without any escape
+// sequences.
+func (p *plainWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+
+	lines := p.buf[:i+1]
+	p.buf = bytes.Clone(p.buf[i+1:])
+	if _, err := p.w.Write(ansiPattern.ReplaceAll(lines, nil)); err != nil {
+		return len(b), err
+	}
+	return len(b), nil
+}
+
+// Flush writes any trailing partial line.
+func (p *plainWriter) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) == 0 {
+		return nil
+	}
+
+	line := p.buf
+	p.buf = nil
+	_, err := p.w.Write(ansiPattern.ReplaceAll(line, nil))
+	return err
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestLogger_WithLog(t *testing.T) {
+	g := ghost.New(t)
+
+	noColor := color.NoColor
+	t.Cleanup(func() { color.NoColor = noColor })
+	color.NoColor = false
+
+	var stdout, stderr, log bytes.Buffer
+	logger := New(Config{Stdout: &stdout, Stderr: &stderr})
+
+	logged := logger.WithLog(&log)
+	fmt.Fprintln(logged.Stdout(), "out")
+	logged.Warn("careful")
+	fmt.Fprintln(logger.Stdout(), "not logged")
+
+	g.Should(be.Equal(stdout.String(), "out\nnot logged\n"))
+	g.Should(be.StringContaining(stderr.String(), "\x1b["))
+	g.Should(be.Equal(log.String(), "out\nWarning careful\n"))
+}
+
+func TestLogger_WithLog_prefix(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout, log bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+
+	logged := logger.WithPrefix("foo").WithLog(&log)
+	fmt.Fprint(logged.Stdout(), "hello")
+	logged.Flush()
+
+	g.Should(be.Equal(stdout.String(), "foo | hello\n"))
+	g.Should(be.Equal(log.String(), "hello"))
+}
+
+func TestLogger_WithLog_split_escape(t *testing.T) {
+	g := ghost.New(t)
+
+	var stdout, log bytes.Buffer
+	logger := New(Config{Stdout: &stdout})
+
+	logged := logger.WithLog(&log)
+	fmt.Fprint(logged.Stdout(), "\x1b[3")
+	fmt.Fprint(logged.Stdout(), "1mred\x1b[0m\npartial\x1b")
+	fmt.Fprint(logged.Stdout(), "[0m")
+
+	g.Should(be.Equal(log.String(), "red\n"))
+
+	logged.FlushLog()
+
+	g.Should(be.Equal(stdout.String(), "\x1b[31mred\x1b[0m\npartial\x1b[0m"))
+	g.Should(be.Equal(log.String(), "red\npartial"))
+}
+
+func TestLogger_WithLog_json(t *testing.T) {
+	g := ghost.New(t)
+
+	var stderr, log bytes.Buffer
+	logger := New(Config{Stderr: &stderr})
+	logger.SetFormat(FormatJSON)
+	logger.sink.(*jsonSink).now = func() time.Time { return time.Time{} }
+
+	logged := logger.WithLog(&log)
+	logged.sink.(multiSink)[1].(*jsonSink).now = func() time.Time { return time.Time{} }
+	fmt.Fprintln(logged.Stdout(), "hello")
+
+	want := `{"time":"0001-01-01T00:00:00Z","type":"output","stream":"stdout","text":"hello"}` + "\n"
+	g.Should(be.Equal(stderr.String(), want))
+	g.Should(be.Equal(log.String(), want))
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	timingsString = "Timings"
+	cachedString  = "cached"
+
+	taskKind    = "task"
+	commandKind = "command"
+)
+
+// timing is the time spent on a single task or command.
+type timing struct {
+	kind    string
+	name    string
+	elapsed time.Duration
+	cached  bool
+}
+
+// timings records how long each task and command takes.
+type timings struct {
+	mu      sync.Mutex
+	entries []timing
+}
+
+func (t *timings) record(entry timing) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = append(t.entries, entry)
+}
+
+// RecordTimings enables recording the time spent on each task and command,
+// to be printed with [Logger.PrintTimings].
+func (l *Logger) RecordTimings() {
+	if l.timings == nil {
+		l.timings = new(timings)
+	}
+}
+
+// PrintTimings prints the time spent on each task and command, slowest first.
+// Tasks skipped because their targets were up to date are listed as cached.
+//
+// Nothing is printed unless timings have been recorded.
+func (l *Logger) PrintTimings() {
+	if l.timings == nil || l.level <= LevelSilent || l.sink != nil {
+		return
+	}
+
+	l.timings.mu.Lock()
+	entries := slices.Clone(l.timings.entries)
+	l.timings.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(entries, func(a, b timing) int {
+		return cmp.Compare(b.elapsed, a.elapsed)
+	})
+
+	durations := make([]string, len(entries))
+	var width int
+	for i, entry := range entries {
+		durations[i] = formatDuration(entry.elapsed)
+		if entry.cached {
+			durations[i] = cachedString
+		}
+		width = max(width, len(durations[i]))
+	}
+
+	f := blue
+
+	fmt.Fprintln(l.Stderr(), f(timingsString))
+	for i, entry := range entries {
+		fmt.Fprintf(
+			l.Stderr(),
+			"%s%*s  %-*s  %s\n",
+			f(outputPrefix),
+			width, durations[i],
+			len(commandKind), entry.kind,
+			bold(entry.name),
+		)
+	}
+}
+
+// formatDuration returns a duration with a precision suitable for display.
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// commandName returns the name of a command within its tasks.
+func commandName(command string, namespaces []string) string {
+	if len(namespaces) == 0 {
+		return command
+	}
+
+	return strings.Join(namespaces, namespaceSeparator) + " " + promptCharacter + " " + command
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rliebz/ghost"
+	"github.com/rliebz/ghost/be"
+)
+
+func TestLogger_PrintTimings(t *testing.T) {
+	g := ghost.New(t)
+
+	var stderr bytes.Buffer
+	logger := New(Config{Stderr: &stderr, Verbosity: LevelQuiet})
+	logger.RecordTimings()
+
+	logger.PrintTaskUpToDate("lint")
+	logger.PrintCommandCompleted("go build", 0, 1200*time.Millisecond, "release", "build")
+	logger.PrintTaskCompleted("build", 1300*time.Millisecond)
+	logger.PrintCommandCompleted("echo done", 0, 5*time.Millisecond, "release")
+	logger.PrintTaskCompleted("release", 12*time.Second)
+
+	logger.PrintTimings()
+
+	g.Should(be.Equal(stderr.String(), `Timings
+ =>    12s  task     release
+ =>   1.3s  task     build
+ =>   1.2s  command  release > build $ go build
+ =>    5ms  command  release $ echo done
+ => cached  task     lint
+`))
+}
+
+func TestLogger_PrintTimings_disabled(t *testing.T) {
+	g := ghost.New(t)
+
+	var stderr bytes.Buffer
+	logger := New(Config{Stderr: &stderr, Verbosity: LevelQuiet})
+
+	logger.PrintTaskCompleted("release", 12*time.Second)
+	logger.PrintTimings()
+
+	g.Should(be.Equal(stderr.String(), ""))
+}